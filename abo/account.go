@@ -0,0 +1,123 @@
+package abo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Account is a Czech bank account number
+type Account struct {
+	Prefix   int
+	Number   int
+	BankCode int
+}
+
+// String formats the account as prefix-number/bank, omitting zero prefix and unknown bank
+func (acc Account) String() string {
+	str := strconv.Itoa(acc.Number)
+	if acc.Prefix != 0 {
+		str = strconv.Itoa(acc.Prefix) + "-" + str
+	}
+	if acc.BankCode != 0 {
+		str += fmt.Sprintf("/%04d", acc.BankCode)
+	}
+	return str
+}
+
+// MarshalText formats the account as prefix-number/bank
+func (acc Account) MarshalText() ([]byte, error) {
+	return []byte(acc.String()), nil
+}
+
+// UnmarshalText parses the account in prefix-number/bank format
+func (acc *Account) UnmarshalText(text []byte) error {
+	parsed, err := ParseAccount(string(text))
+	if err != nil {
+		return err
+	}
+	*acc = parsed
+	return nil
+}
+
+// Symbols are the variable, constant and specific symbols of a payment
+type Symbols struct {
+	VS int `json:"vs,omitempty"`
+	KS int `json:"ks,omitempty"`
+	SS int `json:"ss,omitempty"`
+}
+
+var symbolRe = regexp.MustCompile(`(?i)(?:^|[^A-Z])(VS|KS|SS)[\s:/.=]*(\d{1,10})`)
+
+// ParseSymbols extracts symbols from references such as "/VS/123/SS/456/KS/0308"
+// or "VS: 123". The first occurrence of each symbol in the texts wins.
+func ParseSymbols(texts ...string) Symbols {
+	var sym Symbols
+	var vsSet, ksSet, ssSet bool
+
+	for _, text := range texts {
+		for _, m := range symbolRe.FindAllStringSubmatch(text, -1) {
+			val, err := strconv.Atoi(m[2])
+			if err != nil {
+				continue
+			}
+
+			switch strings.ToUpper(m[1]) {
+			case "VS":
+				if !vsSet {
+					sym.VS, vsSet = val, true
+				}
+			case "KS":
+				if !ksSet {
+					sym.KS, ksSet = val, true
+				}
+			case "SS":
+				if !ssSet {
+					sym.SS, ssSet = val, true
+				}
+			}
+		}
+	}
+
+	return sym
+}
+
+// ParseAccount parses account number in [prefix-]number[/bank] format
+func ParseAccount(str string) (Account, error) {
+	var acc Account
+	var err error
+
+	str = strings.TrimSpace(str)
+	if idx := strings.IndexByte(str, '/'); idx >= 0 {
+		if acc.BankCode, err = strconv.Atoi(str[idx+1:]); err != nil {
+			return Account{}, newErr("invalid bank code in account %s", str)
+		}
+		str = str[:idx]
+	}
+	if idx := strings.IndexByte(str, '-'); idx >= 0 {
+		if acc.Prefix, err = strconv.Atoi(str[:idx]); err != nil {
+			return Account{}, newErr("invalid prefix in account %s", str)
+		}
+		str = str[idx+1:]
+	}
+	if acc.Number, err = strconv.Atoi(str); err != nil {
+		return Account{}, newErr("invalid account number %s", str)
+	}
+
+	if acc.Prefix < 0 || acc.Prefix > 999999 || acc.Number < 0 || acc.Number > 9999999999 || acc.BankCode < 0 || acc.BankCode > 9999 {
+		return Account{}, newErr("account number %s out of range", str)
+	}
+
+	return acc, nil
+}
+
+// accountFromNumber splits 16-digit account number used in GPC into prefix and number
+func accountFromNumber(num int) Account {
+	return Account{Prefix: num / 10000000000, Number: num % 10000000000}
+}
+
+// fullNumber returns 16-digit account number including prefix as used in GPC
+func (acc Account) fullNumber() int {
+	return acc.Prefix*10000000000 + acc.Number
+}
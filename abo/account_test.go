@@ -0,0 +1,40 @@
+package abo
+
+import "testing"
+
+func TestParseAccount(t *testing.T) {
+	acc, err := ParseAccount("19-2000145399/0800")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc != (Account{19, 2000145399, 800}) || acc.String() != "19-2000145399/0800" {
+		t.Fatalf("bad account %v", acc)
+	}
+
+	acc, err = ParseAccount("2101135843/2010")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.String() != "2101135843/2010" {
+		t.Fatalf("bad account %v", acc)
+	}
+
+	if _, err := ParseAccount("12345678901/0800"); err == nil {
+		t.Fatal("expected error for too long account number")
+	}
+}
+
+func TestParseSymbols(t *testing.T) {
+	tests := map[string]Symbols{
+		"/VS/1446556401/SS/7815392681/KS/0308": {1446556401, 308, 7815392681},
+		"Platba VS: 123, KS 0558":              {123, 558, 0},
+		"ADVS12 vs123":                         {123, 0, 0},
+		"":                                     {},
+	}
+
+	for text, exp := range tests {
+		if sym := ParseSymbols(text); sym != exp {
+			t.Fatalf("expected %v for %q, got %v", exp, text, sym)
+		}
+	}
+}
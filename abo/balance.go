@@ -0,0 +1,112 @@
+package abo
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+// RunningBalance is the account balance after a transaction
+type RunningBalance struct {
+	Transaction *Transaction
+	Balance     float64
+}
+
+// RunningBalances returns balances after each transaction in statement order,
+// starting from the opening balance
+func (s *Statement) RunningBalances() []RunningBalance {
+	res := make([]RunningBalance, len(s.Transactions))
+	bal := ToHalere(s.Info.OpeningBalance)
+
+	for i, txn := range s.Transactions {
+		bal += ToHalere(txn.signedAmount())
+		res[i] = RunningBalance{txn, float64(bal) / 100}
+	}
+
+	return res
+}
+
+// DailyBalance is the end of day balance with the day's turnover
+type DailyBalance struct {
+	Date    time.Time
+	Income  float64
+	Expense float64
+	Balance float64
+}
+
+// DailyBalances returns end of day balances for each day from StartDate to EndDate,
+// extended to the dates of all transactions. Days without transactions carry
+// the previous balance. Dates are midnights in the location of StartDate.
+func (s *Statement) DailyBalances() []DailyBalance {
+	loc := s.Info.StartDate.Location()
+	from, to := CalendarDay(s.Info.StartDate), CalendarDay(s.Info.EndDate)
+	type turnover struct{ income, expense int64 }
+	days := make(map[string]*turnover)
+
+	for _, txn := range s.Transactions {
+		d := CalendarDay(txn.DueDate)
+		if d.Before(from) {
+			from = d
+		}
+		if d.After(to) {
+			to = d
+		}
+
+		t := days[d.Format("20060102")]
+		if t == nil {
+			t = new(turnover)
+			days[d.Format("20060102")] = t
+		}
+		if txn.IsDebit() {
+			t.expense += ToHalere(txn.Amount)
+		} else {
+			t.income += ToHalere(txn.Amount)
+		}
+	}
+
+	var res []DailyBalance
+	bal := ToHalere(s.Info.OpeningBalance)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		db := DailyBalance{Date: time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)}
+		if t := days[d.Format("20060102")]; t != nil {
+			bal += t.income - t.expense
+			db.Income = float64(t.income) / 100
+			db.Expense = float64(t.expense) / 100
+		}
+		db.Balance = float64(bal) / 100
+		res = append(res, db)
+	}
+
+	return res
+}
+
+// WriteDailyBalancesCSV writes daily balances as CSV with date, income, expense
+// and balance columns. Columns of the options are ignored.
+func (s *Statement) WriteDailyBalancesCSV(wr io.Writer, opts CSVOptions) error {
+	if opts.BOM {
+		if _, err := io.WriteString(wr, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(wr)
+	cw.Comma = opts.delimiter()
+
+	if err := cw.Write([]string{"date", "income", "expense", "balance"}); err != nil {
+		return err
+	}
+	for _, db := range s.DailyBalances() {
+		row := []string{
+			db.Date.Format(opts.dateFormat()),
+			opts.formatAmount(db.Income),
+			opts.formatAmount(db.Expense),
+			opts.formatAmount(db.Balance),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
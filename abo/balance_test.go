@@ -0,0 +1,76 @@
+package abo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func balanceStatement() *Statement {
+	day := time.Date(2024, 9, 1, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	s := new(Statement)
+	s.Info.StartDate = day
+	s.Info.EndDate = day.AddDate(0, 0, 3)
+	s.Info.OpeningBalance = 1000
+	s.Transactions = []*Transaction{
+		{Amount: 100.1, Type: TypeCredit, DueDate: day},
+		{Amount: 50.2, Type: TypeDebit, DueDate: day},
+		{Amount: 0.1, Type: TypeStornoCredit, DueDate: day.AddDate(0, 0, 2)},
+	}
+	return s
+}
+
+func TestRunningBalances(t *testing.T) {
+	bals := balanceStatement().RunningBalances()
+
+	exp := []float64{1100.1, 1049.9, 1049.8}
+	for i, b := range bals {
+		if b.Balance != exp[i] {
+			t.Fatalf("balance %d: expected %v, got %v", i, exp[i], b.Balance)
+		}
+	}
+}
+
+func TestDailyBalances(t *testing.T) {
+	s := balanceStatement()
+
+	bals := s.DailyBalances()
+	if len(bals) != 4 {
+		t.Fatalf("expected 4 days, got %d", len(bals))
+	}
+	exp := []DailyBalance{
+		{s.Info.StartDate, 100.1, 50.2, 1049.9},
+		{s.Info.StartDate.AddDate(0, 0, 1), 0, 0, 1049.9},
+		{s.Info.StartDate.AddDate(0, 0, 2), 0, 0.1, 1049.8},
+		{s.Info.StartDate.AddDate(0, 0, 3), 0, 0, 1049.8},
+	}
+	for i, b := range bals {
+		if !b.Date.Equal(exp[i].Date) || b.Date.Location() != s.Info.StartDate.Location() || b.Income != exp[i].Income || b.Expense != exp[i].Expense || b.Balance != exp[i].Balance {
+			t.Fatalf("day %d: expected %+v, got %+v", i, exp[i], b)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteDailyBalancesCSV(&buf, CSVOptions{Delimiter: ';', DecimalComma: true}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "date;income;expense;balance" || lines[1] != "2024-09-01;100,10;50,20;1049,90" {
+		t.Fatalf("unexpected CSV\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := s.WriteCSV(&buf, CSVOptions{Columns: []CSVColumn{CSVAmount, CSVBalance}}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "amount,balance\n100.10,1100.10\n-50.20,1049.90\n-0.10,1049.80\n" {
+		t.Fatalf("unexpected CSV\n%s", buf.String())
+	}
+
+	m := CSVMapping{Headers: map[CSVColumn]string{CSVAmount: "amount", CSVBalance: "balance"}}
+	if _, err := FromCSV(&buf, m); err == nil || !strings.Contains(err.Error(), "balance") {
+		t.Fatalf("balance column not rejected: %v", err)
+	}
+}
@@ -0,0 +1,549 @@
+package abo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// camt053 reading structures, tolerant to differences between camt.053 versions
+
+type camtAmount struct {
+	Ccy   string  `xml:"Ccy,attr"`
+	Value float64 `xml:",chardata"`
+}
+
+type camtDate struct {
+	Dt   string
+	DtTm string
+}
+
+func (d camtDate) time() time.Time {
+	if d.Dt != "" {
+		tm, _ := time.Parse("2006-01-02", d.Dt) //nolint:gosec,zero on error
+		return tm
+	}
+	if len(d.DtTm) >= 10 {
+		tm, _ := time.Parse("2006-01-02", d.DtTm[:10]) //nolint:gosec,zero on error
+		return tm
+	}
+	return time.Time{}
+}
+
+type camtAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+	Othr string `xml:"Id>Othr>Id"`
+	Ccy  string
+	Nm   string
+	Ownr string `xml:"Ownr>Nm"`
+}
+
+// account returns Czech account number of IBAN or other identification
+func (a camtAccount) account() Account {
+	if a.IBAN != "" {
+		if acc, err := AccountFromIBAN(a.IBAN); err == nil {
+			return acc
+		}
+	}
+	if acc, err := ParseAccount(a.Othr); err == nil {
+		return acc
+	}
+	return Account{}
+}
+
+type camtParty struct {
+	Nm    string
+	PtyNm string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	if p.Nm != "" {
+		return p.Nm
+	}
+	return p.PtyNm
+}
+
+type camtTxDetails struct {
+	Refs struct {
+		EndToEndID  string `xml:"EndToEndId"`
+		AcctSvcrRef string
+		InstrID     string `xml:"InstrId"`
+		TxID        string `xml:"TxId"`
+	}
+	Amt      *camtAmount
+	TxAmt    *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Dbtr     camtParty   `xml:"RltdPties>Dbtr"`
+	DbtrAcct camtAccount `xml:"RltdPties>DbtrAcct"`
+	Cdtr     camtParty   `xml:"RltdPties>Cdtr"`
+	CdtrAcct camtAccount `xml:"RltdPties>CdtrAcct"`
+	DbtrAgt  string      `xml:"RltdAgts>DbtrAgt>FinInstnId>ClrSysMmbId>MmbId"`
+	CdtrAgt  string      `xml:"RltdAgts>CdtrAgt>FinInstnId>ClrSysMmbId>MmbId"`
+	Ustrd    []string    `xml:"RmtInf>Ustrd"`
+	StrdRef  []string    `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AddtlInf string      `xml:"AddtlTxInf"`
+}
+
+type camtEntry struct {
+	NtryRef     string
+	Amt         camtAmount
+	CdtDbtInd   string
+	RvslInd     bool
+	BookgDt     camtDate
+	ValDt       camtDate
+	AcctSvcrRef string
+	TxDtls      []camtTxDetails `xml:"NtryDtls>TxDtls"`
+	AddtlInf    string          `xml:"AddtlNtryInf"`
+}
+
+type camtBalance struct {
+	Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount
+	CdtDbtInd string
+	Dt        camtDate
+}
+
+func (b camtBalance) amount() float64 {
+	if b.CdtDbtInd == "DBIT" {
+		return -b.Amt.Value
+	}
+	return b.Amt.Value
+}
+
+type camtStatement struct {
+	ID           string `xml:"Id"`
+	ElctrncSeqNb string
+	LglSeqNb     string
+	FrDtTm       string `xml:"FrToDt>FrDtTm"`
+	ToDtTm       string `xml:"FrToDt>ToDtTm"`
+	Acct         camtAccount
+	Bal          []camtBalance
+	Ntry         []camtEntry
+}
+
+type camtDocument struct {
+	Stmt []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+// refID converts a numeric reference to transaction ID
+func refID(refs ...string) int {
+	for _, ref := range refs {
+		if id, err := strconv.Atoi(strings.TrimSpace(ref)); err == nil {
+			return id
+		}
+	}
+	return 0
+}
+
+func (e *camtEntry) transactions(owner int) []*Transaction {
+	details := e.TxDtls
+	if len(details) == 0 {
+		// entry without details is a single transaction
+		details = []camtTxDetails{{}}
+	}
+
+	var txns []*Transaction
+	for _, d := range details {
+		txn := &Transaction{OwnerAccountNumber: owner}
+
+		txn.ID = refID(d.Refs.AcctSvcrRef, e.AcctSvcrRef, e.NtryRef, d.Refs.TxID)
+
+		amt := e.Amt
+		switch {
+		case d.Amt != nil:
+			amt = *d.Amt
+		case d.TxAmt != nil && len(details) > 1:
+			amt = *d.TxAmt
+		}
+		txn.Amount = amt.Value
+		txn.Currency = currency.FromString(amt.Ccy)
+
+		// counterparty is the debtor of a credit and the creditor of a debit
+		var party camtParty
+		var partyAcct camtAccount
+		var partyBank string
+		switch {
+		case e.CdtDbtInd == "CRDT" && !e.RvslInd:
+			txn.Type = TypeCredit
+			party, partyAcct, partyBank = d.Dbtr, d.DbtrAcct, d.DbtrAgt
+		case e.CdtDbtInd == "CRDT":
+			txn.Type = TypeStornoDebit
+			party, partyAcct, partyBank = d.Cdtr, d.CdtrAcct, d.CdtrAgt
+		case !e.RvslInd:
+			txn.Type = TypeDebit
+			party, partyAcct, partyBank = d.Cdtr, d.CdtrAcct, d.CdtrAgt
+		default:
+			txn.Type = TypeStornoCredit
+			party, partyAcct, partyBank = d.Dbtr, d.DbtrAcct, d.DbtrAgt
+		}
+
+		acc := partyAcct.account()
+		if acc.BankCode == 0 {
+			acc.BankCode, _ = strconv.Atoi(partyBank) //nolint:gosec,zero if unknown
+		}
+		txn.Recipient.Name = party.name()
+		txn.Recipient.AccountNumPrefix = acc.Prefix
+		txn.Recipient.AccountNum = acc.Number
+		txn.Recipient.BankCode = acc.BankCode
+
+		texts := append([]string{d.Refs.EndToEndID, d.Refs.InstrID}, d.StrdRef...)
+		texts = append(texts, d.Ustrd...)
+		texts = append(texts, d.AddtlInf, e.AddtlInf)
+		sym := ParseSymbols(texts...)
+		txn.VS, txn.KS, txn.SS = sym.VS, sym.KS, sym.SS
+
+		txn.DueDate = e.ValDt.time()
+		if txn.DueDate.IsZero() {
+			txn.DueDate = e.BookgDt.time()
+		}
+
+		txns = append(txns, txn)
+	}
+
+	return txns
+}
+
+func (cs *camtStatement) statement() *Statement {
+	s := new(Statement)
+
+	acc := cs.Acct.account()
+	s.Info.AccountNumber = acc.fullNumber()
+	s.Info.BankCode = acc.BankCode
+	s.Info.Currency = currency.FromString(cs.Acct.Ccy)
+	s.Info.AccountName = cs.Acct.Nm
+	if s.Info.AccountName == "" {
+		s.Info.AccountName = cs.Acct.Ownr
+	}
+
+	s.Info.StatementNumber = refID(cs.ElctrncSeqNb, cs.LglSeqNb)
+	s.Info.StartDate = camtDate{DtTm: cs.FrDtTm}.time()
+	s.Info.EndDate = camtDate{DtTm: cs.ToDtTm}.time()
+
+	for _, bal := range cs.Bal {
+		switch bal.Cd {
+		case "OPBD", "PRCD":
+			s.Info.OpeningBalance = bal.amount()
+			if s.Info.StartDate.IsZero() {
+				s.Info.StartDate = bal.Dt.time()
+			}
+		case "CLBD":
+			s.Info.ClosingBalance = bal.amount()
+			if s.Info.EndDate.IsZero() {
+				s.Info.EndDate = bal.Dt.time()
+			}
+		default:
+			continue
+		}
+		if s.Info.Currency == currency.Unknown {
+			s.Info.Currency = currency.FromString(bal.Amt.Ccy)
+		}
+	}
+
+	s.Transactions = []*Transaction{}
+	for i := range cs.Ntry {
+		for _, txn := range cs.Ntry[i].transactions(s.Info.AccountNumber) {
+			if txn.IsDebit() {
+				s.Info.ExpenseSum += txn.Amount
+			} else {
+				s.Info.IncomeSum += txn.Amount
+			}
+			s.Transactions = append(s.Transactions, txn)
+		}
+	}
+	s.Info.IncomeSum = roundAmount(s.Info.IncomeSum)
+	s.Info.ExpenseSum = roundAmount(s.Info.ExpenseSum)
+
+	return s
+}
+
+// FromCamt053All parses all statements of ISO 20022 camt.053 document
+func FromCamt053All(rdr io.Reader) ([]*Statement, error) {
+	// close input if possible
+	defer func() {
+		if rdrc, ok := rdr.(io.ReadCloser); ok {
+			rdrc.Close() //nolint:gosec
+		}
+	}()
+
+	var doc camtDocument
+	if err := xml.NewDecoder(rdr).Decode(&doc); err != nil {
+		return nil, newErr("unable to parse camt.053: %v", err)
+	}
+
+	stmts := make([]*Statement, 0, len(doc.Stmt))
+	for i := range doc.Stmt {
+		stmts = append(stmts, doc.Stmt[i].statement())
+	}
+
+	return stmts, nil
+}
+
+// FromCamt053 parses ISO 20022 camt.053 document returning its first statement
+func FromCamt053(rdr io.Reader) (*Statement, error) {
+	stmts, err := FromCamt053All(rdr)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) == 0 {
+		return nil, newErr("no statement in camt.053 document")
+	}
+
+	return stmts[0], nil
+}
+
+// camt053 writing structures in the element order of camt.053.001.02
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtOutDocument struct {
+	XMLName xml.Name         `xml:"Document"`
+	Xmlns   string           `xml:"xmlns,attr"`
+	MsgID   string           `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreDtTm string           `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Stmt    camtOutStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtOutStatement struct {
+	ID           string `xml:"Id"`
+	ElctrncSeqNb int
+	CreDtTm      string
+	FrDtTm       string `xml:"FrToDt>FrDtTm"`
+	ToDtTm       string `xml:"FrToDt>ToDtTm"`
+	Acct         camtOutAccount
+	Bal          []camtOutBalance
+	Ntry         []camtOutEntry
+}
+
+type camtOutAccount struct {
+	ID  camtOutAccountID `xml:"Id"`
+	Ccy string           `xml:",omitempty"`
+	Nm  string           `xml:",omitempty"`
+}
+
+type camtOutAccountID struct {
+	IBAN string `xml:",omitempty"`
+	Othr *struct {
+		ID string `xml:"Id"`
+	}
+}
+
+type camtOutDate struct {
+	Dt string
+}
+
+type camtOutBalance struct {
+	Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       painAmount
+	CdtDbtInd string
+	Dt        camtOutDate
+}
+
+type camtOutEntry struct {
+	Amt         painAmount
+	CdtDbtInd   string
+	RvslInd     bool `xml:",omitempty"`
+	Sts         string
+	BookgDt     camtOutDate
+	ValDt       camtOutDate
+	AcctSvcrRef string           `xml:",omitempty"`
+	DomnCd      string           `xml:"BkTxCd>Domn>Cd"`
+	FmlyCd      string           `xml:"BkTxCd>Domn>Fmly>Cd"`
+	SubFmlyCd   string           `xml:"BkTxCd>Domn>Fmly>SubFmlyCd"`
+	TxDtls      camtOutTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtOutParty struct {
+	Nm string `xml:",omitempty"`
+}
+
+type camtOutAgent struct {
+	MmbID string `xml:"FinInstnId>ClrSysMmbId>MmbId"`
+}
+
+type camtOutTxDetails struct {
+	Refs *struct {
+		AcctSvcrRef string `xml:",omitempty"`
+		EndToEndID  string `xml:"EndToEndId,omitempty"`
+	}
+	RltdPties *struct {
+		Dbtr     *camtOutParty
+		DbtrAcct *camtOutAccount
+		Cdtr     *camtOutParty
+		CdtrAcct *camtOutAccount
+	}
+	RltdAgts *struct {
+		DbtrAgt *camtOutAgent
+		CdtrAgt *camtOutAgent
+	}
+	Ustrd string `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// camtOutAccountOf returns account identification, IBAN for accounts with bank code
+func camtOutAccountOf(acc Account) camtOutAccountID {
+	if acc.BankCode != 0 {
+		return camtOutAccountID{IBAN: acc.IBAN()}
+	}
+
+	id := camtOutAccountID{Othr: &struct {
+		ID string `xml:"Id"`
+	}{}}
+	if acc.Prefix != 0 {
+		id.Othr.ID = strconv.Itoa(acc.Prefix) + "-"
+	}
+	id.Othr.ID += strconv.Itoa(acc.Number)
+
+	return id
+}
+
+// camtCreditDebit returns credit/debit indicator and absolute value of a balance
+func camtCreditDebit(amount float64) (string, float64) {
+	if amount < 0 {
+		return "DBIT", -amount
+	}
+	return "CRDT", amount
+}
+
+// symbolsRef formats symbols using the Czech /VS/.../SS/.../KS/... convention
+func symbolsRef(vs, ks, ss int) string {
+	if vs == 0 && ks == 0 && ss == 0 {
+		return ""
+	}
+	return fmt.Sprintf("/VS/%d/SS/%d/KS/%d", vs, ss, ks)
+}
+
+// currency returns currency of the statement, derived from transactions if unknown
+func (s *Statement) currency() currency.Currency {
+	if s.Info.Currency != currency.Unknown {
+		return s.Info.Currency
+	}
+	for _, txn := range s.Transactions {
+		if txn.Currency != currency.Unknown {
+			return txn.Currency
+		}
+	}
+	return currency.CZK
+}
+
+func (s *Statement) camtEntry(txn *Transaction, ccy currency.Currency) camtOutEntry {
+	if txn.Currency != currency.Unknown {
+		ccy = txn.Currency
+	}
+
+	e := camtOutEntry{
+		Amt:       painAmount{ccy.String(), formatAmount(txn.Amount)},
+		CdtDbtInd: "CRDT",
+		RvslInd:   txn.IsReversal(),
+		Sts:       "BOOK",
+		BookgDt:   camtOutDate{txn.DueDate.Format("2006-01-02")},
+		ValDt:     camtOutDate{txn.DueDate.Format("2006-01-02")},
+		DomnCd:    "PMNT",
+		FmlyCd:    "RCDT",
+		SubFmlyCd: "DMCT",
+	}
+	if txn.IsDebit() {
+		e.CdtDbtInd = "DBIT"
+		e.FmlyCd = "ICDT"
+	}
+	if txn.ID != 0 {
+		e.AcctSvcrRef = strconv.Itoa(txn.ID)
+	}
+
+	d := &e.TxDtls
+	ref := symbolsRef(txn.VS, txn.KS, txn.SS)
+	if e.AcctSvcrRef != "" || ref != "" {
+		d.Refs = &struct {
+			AcctSvcrRef string `xml:",omitempty"`
+			EndToEndID  string `xml:"EndToEndId,omitempty"`
+		}{e.AcctSvcrRef, ref}
+	}
+	d.Ustrd = ref
+
+	// counterparty is the debtor of a credit and the creditor of a debit,
+	// reversals swap the sides
+	if txn.Recipient.AccountNum != 0 || txn.Recipient.Name != "" {
+		acc := Account{txn.Recipient.AccountNumPrefix, txn.Recipient.AccountNum, txn.Recipient.BankCode}
+		party := &camtOutParty{txn.Recipient.Name}
+		var partyAcct *camtOutAccount
+		if acc.Number != 0 {
+			partyAcct = &camtOutAccount{ID: camtOutAccountOf(acc)}
+		}
+
+		d.RltdPties = &struct {
+			Dbtr     *camtOutParty
+			DbtrAcct *camtOutAccount
+			Cdtr     *camtOutParty
+			CdtrAcct *camtOutAccount
+		}{}
+		if txn.IsDebit() != txn.IsReversal() {
+			d.RltdPties.Cdtr, d.RltdPties.CdtrAcct = party, partyAcct
+		} else {
+			d.RltdPties.Dbtr, d.RltdPties.DbtrAcct = party, partyAcct
+		}
+
+		if acc.BankCode != 0 {
+			agent := &camtOutAgent{fmt.Sprintf("%04d", acc.BankCode)}
+			d.RltdAgts = &struct {
+				DbtrAgt *camtOutAgent
+				CdtrAgt *camtOutAgent
+			}{}
+			if d.RltdPties.Cdtr != nil {
+				d.RltdAgts.CdtrAgt = agent
+			} else {
+				d.RltdAgts.DbtrAgt = agent
+			}
+		}
+	}
+
+	return e
+}
+
+// WriteCamt053 writes the statement as ISO 20022 camt.053.001.02 document.
+// Account is identified by IBAN if Info.BankCode is known.
+func (s *Statement) WriteCamt053(wr io.Writer) error {
+	ccy := s.currency()
+	acc := accountFromNumber(s.Info.AccountNumber)
+	acc.BankCode = s.Info.BankCode
+	now := time.Now().Format("2006-01-02T15:04:05")
+
+	doc := camtOutDocument{
+		Xmlns:   camt053Namespace,
+		MsgID:   fmt.Sprintf("%d-%s-%03d", s.Info.AccountNumber, s.Info.EndDate.Format("20060102"), s.Info.StatementNumber),
+		CreDtTm: now,
+	}
+
+	stmt := &doc.Stmt
+	stmt.ID = fmt.Sprintf("%d-%03d", s.Info.AccountNumber, s.Info.StatementNumber)
+	stmt.ElctrncSeqNb = s.Info.StatementNumber
+	stmt.CreDtTm = now
+	stmt.FrDtTm = s.Info.StartDate.Format("2006-01-02") + "T00:00:00"
+	stmt.ToDtTm = s.Info.EndDate.Format("2006-01-02") + "T23:59:59"
+	stmt.Acct = camtOutAccount{ID: camtOutAccountOf(acc), Ccy: ccy.String(), Nm: s.Info.AccountName}
+
+	opInd, opAmt := camtCreditDebit(s.Info.OpeningBalance)
+	clInd, clAmt := camtCreditDebit(s.Info.ClosingBalance)
+	stmt.Bal = []camtOutBalance{
+		{"OPBD", painAmount{ccy.String(), formatAmount(opAmt)}, opInd, camtOutDate{s.Info.StartDate.Format("2006-01-02")}},
+		{"CLBD", painAmount{ccy.String(), formatAmount(clAmt)}, clInd, camtOutDate{s.Info.EndDate.Format("2006-01-02")}},
+	}
+
+	for _, txn := range s.Transactions {
+		stmt.Ntry = append(stmt.Ntry, s.camtEntry(txn, ccy))
+	}
+
+	if _, err := io.WriteString(wr, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(wr)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return newErr("unable to encode camt.053: %w", err)
+	}
+
+	_, err := io.WriteString(wr, "\n")
+	return err
+}
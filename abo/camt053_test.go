@@ -0,0 +1,104 @@
+package abo
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+func TestFromCamt053(t *testing.T) {
+	rdr, err := os.Open("./test/camt053.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromCamt053(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stmt.Info.AccountNumber != 2600113745 || stmt.Info.BankCode != 2010 || stmt.Info.Currency != currency.CZK {
+		t.Fatalf("bad account %d/%d", stmt.Info.AccountNumber, stmt.Info.BankCode)
+	}
+	if stmt.Info.AccountName != "Hros, Mario" || stmt.Info.StatementNumber != 27 {
+		t.Fatal("bad statement info")
+	}
+	if stmt.Info.OpeningBalance != 313174.77 || stmt.Info.ClosingBalance != 310454.19 {
+		t.Fatal("bad balances")
+	}
+	if stmt.Info.IncomeSum != 1234.56 || stmt.Info.ExpenseSum != 3955.14 {
+		t.Fatalf("bad sums %f %f", stmt.Info.IncomeSum, stmt.Info.ExpenseSum)
+	}
+	if stmt.Info.StartDate.Format("2006-01-02") != "2024-09-18" || stmt.Info.EndDate.Format("2006-01-02") != "2024-09-18" {
+		t.Fatal("bad dates")
+	}
+
+	if len(stmt.Transactions) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(stmt.Transactions))
+	}
+
+	tr := stmt.Transactions[0]
+	if tr.Type != 2 || tr.Amount != 1234.56 || tr.ID != 27022700166 {
+		t.Fatal("bad credit transaction")
+	}
+	if tr.Recipient.Name != "CEZ" || tr.Recipient.AccountNumPrefix != 77 || tr.Recipient.AccountNum != 7022700166 || tr.Recipient.BankCode != 100 {
+		t.Fatal("bad counterparty")
+	}
+	if tr.VS != 1446556401 || tr.KS != 308 || tr.SS != 7815392681 {
+		t.Fatal("bad symbols")
+	}
+
+	tr = stmt.Transactions[2]
+	if tr.Type != 1 || tr.Amount != 2000 || tr.ID != 27022700169 || tr.VS != 92024 {
+		t.Fatal("bad batch debit transaction")
+	}
+	if tr.Recipient.AccountNum != 1900133399 || tr.Recipient.BankCode != 2010 {
+		t.Fatal("bad creditor account")
+	}
+}
+
+func TestStatementWriteCamt053(t *testing.T) {
+	rdr, err := os.Open("./test/fio.gpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromReader(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt.Info.BankCode = 2010
+
+	buff := new(bytes.Buffer)
+	if err := stmt.WriteCamt053(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	validateXML(t, buff.Bytes(), "camt.053.001.02.xsd")
+
+	back, err := FromCamt053(buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if back.Info.AccountNumber != stmt.Info.AccountNumber || back.Info.BankCode != 2010 || back.Info.StatementNumber != stmt.Info.StatementNumber {
+		t.Fatal("bad account info")
+	}
+	if back.Info.OpeningBalance != stmt.Info.OpeningBalance || back.Info.ClosingBalance != stmt.Info.ClosingBalance {
+		t.Fatal("bad balances")
+	}
+	if !back.Info.StartDate.Equal(stmt.Info.StartDate) || !back.Info.EndDate.Equal(stmt.Info.EndDate) {
+		t.Fatal("bad dates")
+	}
+	if len(back.Transactions) != len(stmt.Transactions) {
+		t.Fatal("bad number of transactions")
+	}
+
+	for i, txn := range stmt.Transactions {
+		if *back.Transactions[i] != *txn {
+			t.Fatalf("transaction mismatch\n%v\n%v", txn, back.Transactions[i])
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package categorize assigns categories and accounting codes to statement
+// transactions by ordered rules loaded from YAML or JSON.
+package categorize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/k3a/ago/abo"
+	"gopkg.in/yaml.v3"
+)
+
+func newErr(format string, args ...interface{}) error {
+	return fmt.Errorf("categorize: "+format, args...)
+}
+
+// AmountRange limits the transaction amount regardless of direction, zero bound is open
+type AmountRange struct {
+	Min float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Rule assigns a category to transactions matching all its conditions,
+// empty conditions match any transaction
+type Rule struct {
+	// Name identifies the rule in explanations
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Category and Code are assigned to matching transactions
+	Category string `json:"category" yaml:"category"`
+	Code     string `json:"code,omitempty" yaml:"code,omitempty"`
+
+	// Account of the counterparty as [prefix-]number[/bank], bank is compared only if set
+	Account string `json:"account,omitempty" yaml:"account,omitempty"`
+	// BankCode of the counterparty
+	BankCode int `json:"bank,omitempty" yaml:"bank,omitempty"`
+	// Payee is a regular expression matched against the counterparty name
+	Payee string `json:"payee,omitempty" yaml:"payee,omitempty"`
+	// VS, KS and SS are regular expressions matching the whole symbol in decimal
+	VS string `json:"vs,omitempty" yaml:"vs,omitempty"`
+	KS string `json:"ks,omitempty" yaml:"ks,omitempty"`
+	SS string `json:"ss,omitempty" yaml:"ss,omitempty"`
+	// Amount range of the transaction
+	Amount *AmountRange `json:"amount,omitempty" yaml:"amount,omitempty"`
+	// Direction is "debit" or "credit"
+	Direction string `json:"direction,omitempty" yaml:"direction,omitempty"`
+}
+
+// Rules is the content of a rules file
+type Rules struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+type compiledRule struct {
+	*Rule
+	account    *abo.Account
+	payee      *regexp.Regexp
+	vs, ks, ss *regexp.Regexp
+	debit      *bool
+}
+
+// Categorizer assigns categories by the first matching rule
+type Categorizer struct {
+	rules []compiledRule
+}
+
+// Result of categorization of a transaction
+type Result struct {
+	Transaction *abo.Transaction
+	// Rule which matched, nil if no rule matched
+	Rule     *Rule
+	Category string
+	Code     string
+	// Explanation describes the matched rule and its conditions
+	Explanation string
+}
+
+func compileSymbol(name, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern %q: %w", name, pattern, err)
+	}
+	return re, nil
+}
+
+// compile checks and compiles the rule, errors are wrapped with the rule number by New
+func compile(r *Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r}
+	var err error
+
+	if r.Category == "" && r.Code == "" {
+		return cr, errors.New("missing category or code")
+	}
+	if r.Account != "" {
+		acc, err := abo.ParseAccount(r.Account)
+		if err != nil {
+			return cr, fmt.Errorf("invalid account %q: %w", r.Account, err)
+		}
+		cr.account = &acc
+	}
+	if r.Payee != "" {
+		if cr.payee, err = regexp.Compile(r.Payee); err != nil {
+			return cr, fmt.Errorf("invalid payee pattern %q: %w", r.Payee, err)
+		}
+	}
+	if cr.vs, err = compileSymbol("VS", r.VS); err != nil {
+		return cr, err
+	}
+	if cr.ks, err = compileSymbol("KS", r.KS); err != nil {
+		return cr, err
+	}
+	if cr.ss, err = compileSymbol("SS", r.SS); err != nil {
+		return cr, err
+	}
+	switch strings.ToLower(r.Direction) {
+	case "":
+	case "debit":
+		cr.debit = new(bool)
+		*cr.debit = true
+	case "credit":
+		cr.debit = new(bool)
+	default:
+		return cr, fmt.Errorf("invalid direction %q", r.Direction)
+	}
+
+	return cr, nil
+}
+
+// New compiles the rules, evaluated in the given order
+func New(rules []Rule) (*Categorizer, error) {
+	c := &Categorizer{rules: make([]compiledRule, len(rules))}
+	for i := range rules {
+		cr, err := compile(&rules[i])
+		if err != nil {
+			return nil, newErr("rule %d: %w", i+1, err)
+		}
+		c.rules[i] = cr
+	}
+	return c, nil
+}
+
+// Load reads rules from YAML or JSON, at least one rule is required
+func Load(rdr io.Reader) (*Categorizer, error) {
+	var rules Rules
+	dec := yaml.NewDecoder(rdr)
+	dec.KnownFields(true)
+	if err := dec.Decode(&rules); err != nil && err != io.EOF {
+		return nil, newErr("unable to decode rules: %w", err)
+	}
+	if len(rules.Rules) == 0 {
+		return nil, newErr("no rules")
+	}
+	return New(rules.Rules)
+}
+
+// LoadFile reads rules from a YAML or JSON file
+func LoadFile(name string) (*Categorizer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// match returns matched conditions of the rule, ok is false if any condition doesn't match
+func (cr *compiledRule) match(txn *abo.Transaction) (conds []string, ok bool) {
+	if cr.account != nil {
+		acc := cr.account
+		if txn.Recipient.AccountNumPrefix != acc.Prefix || txn.Recipient.AccountNum != acc.Number ||
+			(acc.BankCode != 0 && txn.Recipient.BankCode != acc.BankCode) {
+			return nil, false
+		}
+		conds = append(conds, "account "+acc.String())
+	}
+	if cr.BankCode != 0 {
+		if txn.Recipient.BankCode != cr.BankCode {
+			return nil, false
+		}
+		conds = append(conds, fmt.Sprintf("bank %04d", cr.BankCode))
+	}
+	if cr.payee != nil {
+		if !cr.payee.MatchString(txn.Recipient.Name) {
+			return nil, false
+		}
+		conds = append(conds, "payee ~ "+cr.Payee)
+	}
+	for _, sym := range []struct {
+		name string
+		re   *regexp.Regexp
+		val  int
+	}{{"VS", cr.vs, txn.VS}, {"KS", cr.ks, txn.KS}, {"SS", cr.ss, txn.SS}} {
+		if sym.re == nil {
+			continue
+		}
+		if !sym.re.MatchString(strconv.Itoa(sym.val)) {
+			return nil, false
+		}
+		conds = append(conds, fmt.Sprintf("%s %d", sym.name, sym.val))
+	}
+	if cr.Amount != nil {
+		amount := abo.ToHalere(txn.Amount)
+		if (cr.Amount.Min != 0 && amount < abo.ToHalere(cr.Amount.Min)) ||
+			(cr.Amount.Max != 0 && amount > abo.ToHalere(cr.Amount.Max)) {
+			return nil, false
+		}
+		conds = append(conds, fmt.Sprintf("amount %.2f", txn.Amount))
+	}
+	if cr.debit != nil {
+		if txn.IsDebit() != *cr.debit {
+			return nil, false
+		}
+		conds = append(conds, strings.ToLower(cr.Direction))
+	}
+
+	return conds, true
+}
+
+// Categorize returns the category of the first rule matching the transaction
+func (c *Categorizer) Categorize(txn *abo.Transaction) Result {
+	for i := range c.rules {
+		cr := &c.rules[i]
+		conds, ok := cr.match(txn)
+		if !ok {
+			continue
+		}
+
+		name := cr.Name
+		if name == "" {
+			name = "#" + strconv.Itoa(i+1)
+		}
+		expl := "rule " + name
+		if len(conds) > 0 {
+			expl += ": " + strings.Join(conds, ", ")
+		}
+
+		return Result{
+			Transaction: txn,
+			Rule:        cr.Rule,
+			Category:    cr.Category,
+			Code:        cr.Code,
+			Explanation: expl,
+		}
+	}
+
+	return Result{Transaction: txn, Explanation: "no rule matched"}
+}
+
+// Statement categorizes all transactions of the statement
+func (c *Categorizer) Statement(s *abo.Statement) []Result {
+	res := make([]Result, len(s.Transactions))
+	for i, txn := range s.Transactions {
+		res[i] = c.Categorize(txn)
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package categorize
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/k3a/ago/abo"
+)
+
+func TestCategorize(t *testing.T) {
+	c, err := LoadFile("test/rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("test/transactions.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := abo.FromCSV(f, abo.CSVMapping{Headers: map[abo.CSVColumn]string{
+		abo.CSVAmount: "amount", abo.CSVName: "name", abo.CSVAccount: "account",
+		abo.CSVBankCode: "bank", abo.CSVVS: "vs", abo.CSVKS: "ks",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := c.Statement(s)
+
+	exp := []string{"502", "", "518", "", "311", ""}
+	for i, r := range res {
+		if r.Code != exp[i] {
+			t.Fatalf("transaction %d: expected code %q, got %q (%s)", i, exp[i], r.Code, r.Explanation)
+		}
+	}
+	if res[0].Explanation != "rule electricity: account 7770227/0100, KS 308, debit" {
+		t.Fatalf("unexpected explanation %s", res[0].Explanation)
+	}
+	if res[1].Rule != nil || res[1].Explanation != "no rule matched" {
+		t.Fatalf("unexpected result %+v", res[1])
+	}
+	if res[4].Rule.Name != "invoices" || res[4].Category != "Income:Sales" {
+		t.Fatalf("unexpected result %+v", res[4])
+	}
+
+	if c, err = LoadFile("test/rules.json"); err != nil {
+		t.Fatal(err)
+	}
+	if r := c.Categorize(s.Transactions[5]); r.Code != "568" || r.Explanation != "rule bank fees: bank 2010, amount 15.00" {
+		t.Fatalf("unexpected result %+v", r)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, rules := range []string{
+		"rules:\n  - category: X\n    vs: \"(\"\n",
+		"rules:\n  - category: X\n    direction: up\n",
+		"rules:\n  - code: \"\"\n",
+		"rules:\n  - category: X\n    account: abc\n",
+		"rules:\n  - category: X\n    unknown: 1\n",
+		"",
+		" \n",
+		"rules: []\n",
+	} {
+		if _, err := Load(strings.NewReader(rules)); err == nil {
+			t.Fatalf("invalid rules accepted:\n%s", rules)
+		}
+	}
+
+	_, err := New([]Rule{{Category: "X"}, {Category: "Y", Direction: "up"}})
+	if err == nil || err.Error() != `categorize: rule 2: invalid direction "up"` {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, err = New([]Rule{{Category: "X", Account: "abc"}})
+	if err == nil || !strings.HasPrefix(err.Error(), `categorize: rule 1: invalid account "abc": `) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package abo
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GapKind is a kind of discontinuity between consecutive statements
+type GapKind int
+
+// Discontinuity kinds
+const (
+	// GapNumber means statement numbers are not consecutive
+	GapNumber GapKind = iota
+	// GapDate means days between the end date and the next start date are not covered
+	GapDate
+	// GapBalance means the closing balance differs from the next opening balance
+	GapBalance
+	// GapDuplicate means both statements have the same number
+	GapDuplicate
+	// GapOverlap means the next statement starts before the end date of the previous one
+	GapOverlap
+)
+
+func (k GapKind) String() string {
+	switch k {
+	case GapNumber:
+		return "number gap"
+	case GapDate:
+		return "date gap"
+	case GapBalance:
+		return "balance mismatch"
+	case GapDuplicate:
+		return "duplicate number"
+	case GapOverlap:
+		return "date overlap"
+	}
+	return "unknown"
+}
+
+// Discontinuity between two consecutive statements of an account
+type Discontinuity struct {
+	Kind GapKind
+	Prev *Statement
+	Next *Statement
+}
+
+func (d Discontinuity) String() string {
+	prev, next := &d.Prev.Info, &d.Next.Info
+	str := fmt.Sprintf("account %d: %s between statements %d and %d: ", prev.AccountNumber, d.Kind,
+		prev.StatementNumber, next.StatementNumber)
+
+	switch d.Kind {
+	case GapNumber:
+		if next.StatementNumber < prev.StatementNumber {
+			return str + "out of order"
+		}
+		return str + fmt.Sprintf("%d missing", next.StatementNumber-prev.StatementNumber-1)
+	case GapDate:
+		return str + fmt.Sprintf("%s to %s not covered", prev.EndDate.AddDate(0, 0, 1).Format("2006-01-02"),
+			next.StartDate.AddDate(0, 0, -1).Format("2006-01-02"))
+	case GapBalance:
+		return str + fmt.Sprintf("closing %.2f, opening %.2f", prev.ClosingBalance, next.OpeningBalance)
+	case GapDuplicate:
+		return str + "same number"
+	case GapOverlap:
+		end := prev.EndDate
+		if next.EndDate.Before(end) {
+			end = next.EndDate
+		}
+		return str + fmt.Sprintf("%s to %s covered twice", next.StartDate.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	return str
+}
+
+// CheckContinuity orders statements of each account by start date and reports gaps,
+// duplicates and out of order statement numbers, gaps and overlaps in dates and balance
+// mismatches between consecutive statements.
+// Numbering restarting from 1 in a new year is not a gap, zero numbers are not checked.
+func CheckContinuity(stmts ...*Statement) []Discontinuity {
+	accounts := make(map[int][]*Statement)
+	var accNums []int
+	for _, s := range stmts {
+		if _, ok := accounts[s.Info.AccountNumber]; !ok {
+			accNums = append(accNums, s.Info.AccountNumber)
+		}
+		accounts[s.Info.AccountNumber] = append(accounts[s.Info.AccountNumber], s)
+	}
+	sort.Ints(accNums)
+
+	var res []Discontinuity
+	for _, accNum := range accNums {
+		seq := accounts[accNum]
+		sort.SliceStable(seq, func(i, j int) bool {
+			if !seq[i].Info.StartDate.Equal(seq[j].Info.StartDate) {
+				return seq[i].Info.StartDate.Before(seq[j].Info.StartDate)
+			}
+			return seq[i].Info.StatementNumber < seq[j].Info.StatementNumber
+		})
+
+		for i := 1; i < len(seq); i++ {
+			prev, next := seq[i-1], seq[i]
+
+			pn, nn := prev.Info.StatementNumber, next.Info.StatementNumber
+			newYear := nn == 1 && next.Info.StartDate.Year() > prev.Info.EndDate.Year()
+			if pn != 0 && nn == pn {
+				res = append(res, Discontinuity{GapDuplicate, prev, next})
+			} else if pn != 0 && nn != 0 && nn != pn+1 && !newYear {
+				res = append(res, Discontinuity{GapNumber, prev, next})
+			}
+
+			start, end := CalendarDay(next.Info.StartDate), CalendarDay(prev.Info.EndDate)
+			if start.After(end.AddDate(0, 0, 1)) {
+				res = append(res, Discontinuity{GapDate, prev, next})
+			} else if !start.After(end) {
+				res = append(res, Discontinuity{GapOverlap, prev, next})
+			}
+
+			if ToHalere(prev.Info.ClosingBalance) != ToHalere(next.Info.OpeningBalance) {
+				res = append(res, Discontinuity{GapBalance, prev, next})
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,71 @@
+package abo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckContinuity(t *testing.T) {
+	stmt := func(acc, num int, start time.Time, days int, opening, closing float64) *Statement {
+		s := new(Statement)
+		s.Info.AccountNumber = acc
+		s.Info.StatementNumber = num
+		s.Info.StartDate = start
+		s.Info.EndDate = start.AddDate(0, 0, days-1)
+		s.Info.OpeningBalance = opening
+		s.Info.ClosingBalance = closing
+		return s
+	}
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	dec := time.Date(2023, 12, 1, 0, 0, 0, 0, time.Local)
+
+	gaps := CheckContinuity(
+		stmt(1, 2, jan.AddDate(0, 1, 0), 29, 200, 300),
+		stmt(1, 12, dec, 31, 0, 100),
+		stmt(1, 1, jan, 31, 100, 200.1),
+		stmt(1, 5, jan.AddDate(0, 4, 0), 30, 300, 400),
+		stmt(2, 1, jan, 31, 0, 0),
+		stmt(2, 2, jan.AddDate(0, 1, 0), 29, 0, 0),
+	)
+
+	exp := []GapKind{GapBalance, GapNumber, GapDate}
+	if len(gaps) != len(exp) {
+		t.Fatalf("expected %d discontinuities, got %v", len(exp), gaps)
+	}
+	for i, g := range gaps {
+		if g.Kind != exp[i] {
+			t.Fatalf("expected %s, got %s", exp[i], g)
+		}
+	}
+
+	if str := gaps[2].String(); str != "account 1: date gap between statements 2 and 5: 2024-03-01 to 2024-04-30 not covered" {
+		t.Fatalf("unexpected description %s", str)
+	}
+	if str := gaps[0].String(); str != "account 1: balance mismatch between statements 1 and 2: closing 200.10, opening 200.00" {
+		t.Fatalf("unexpected description %s", str)
+	}
+
+	// the same statement twice and a statement with a lower number
+	gaps = CheckContinuity(
+		stmt(3, 7, jan, 31, 0, 0),
+		stmt(3, 7, jan, 31, 0, 0),
+		stmt(3, 6, jan.AddDate(0, 1, 0), 29, 0, 0),
+	)
+
+	exp = []GapKind{GapDuplicate, GapOverlap, GapNumber}
+	if len(gaps) != len(exp) {
+		t.Fatalf("expected %d discontinuities, got %v", len(exp), gaps)
+	}
+	for i, g := range gaps {
+		if g.Kind != exp[i] {
+			t.Fatalf("expected %s, got %s", exp[i], g)
+		}
+	}
+
+	if str := gaps[1].String(); str != "account 3: date overlap between statements 7 and 7: 2024-01-01 to 2024-01-31 covered twice" {
+		t.Fatalf("unexpected description %s", str)
+	}
+	if str := gaps[2].String(); str != "account 3: number gap between statements 7 and 6: out of order" {
+		t.Fatalf("unexpected description %s", str)
+	}
+}
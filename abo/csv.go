@@ -0,0 +1,379 @@
+package abo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// CSVColumn identifies a transaction attribute in CSV
+type CSVColumn string
+
+// CSV columns
+const (
+	CSVID       CSVColumn = "id"
+	CSVDate     CSVColumn = "date"
+	CSVAmount   CSVColumn = "amount" // signed, negative for debits
+	CSVCurrency CSVColumn = "currency"
+	CSVType     CSVColumn = "type"
+	CSVName     CSVColumn = "name"
+	CSVAccount  CSVColumn = "account" // [prefix-]number[/bank]
+	CSVBankCode CSVColumn = "bank"
+	CSVVS       CSVColumn = "vs"
+	CSVKS       CSVColumn = "ks"
+	CSVSS       CSVColumn = "ss"
+	CSVBalance  CSVColumn = "balance" // running balance after the transaction, written only
+)
+
+// DefaultCSVColumns are written if no columns are specified
+var DefaultCSVColumns = []CSVColumn{CSVID, CSVDate, CSVAmount, CSVCurrency, CSVType,
+	CSVName, CSVAccount, CSVVS, CSVKS, CSVSS}
+
+// csvReadColumns are the columns FromCSV reads, in the order they are set,
+// amount last so an explicit type wins
+var csvReadColumns = []CSVColumn{CSVID, CSVDate, CSVCurrency, CSVType, CSVName,
+	CSVAccount, CSVBankCode, CSVVS, CSVKS, CSVSS, CSVAmount}
+
+const utf8BOM = "\ufeff"
+
+// CSVOptions controls CSV format
+type CSVOptions struct {
+	// Columns to write, DefaultCSVColumns if empty
+	Columns []CSVColumn
+	// Delimiter of fields, ',' if zero. Czech Excel expects ';'
+	Delimiter rune
+	// DecimalComma formats amounts with decimal comma
+	DecimalComma bool
+	// DateFormat is time layout of dates, "2006-01-02" if empty
+	DateFormat string
+	// BOM prepends UTF-8 byte order mark so Excel detects the encoding
+	BOM bool
+}
+
+func (opts *CSVOptions) delimiter() rune {
+	if opts.Delimiter == 0 {
+		return ','
+	}
+	return opts.Delimiter
+}
+
+func (opts *CSVOptions) dateFormat() string {
+	if opts.DateFormat == "" {
+		return "2006-01-02"
+	}
+	return opts.DateFormat
+}
+
+func (opts *CSVOptions) formatAmount(amount float64) string {
+	str := formatAmount(amount)
+	if opts.DecimalComma {
+		str = strings.Replace(str, ".", ",", 1)
+	}
+	return str
+}
+
+// parseAmount parses amount with the configured decimal mark. The other mark and spaces
+// are accepted as thousands separators of 3-digit groups only, so amounts using
+// the other decimal mark are rejected instead of being read 100 times larger.
+func (opts *CSVOptions) parseAmount(str string) (float64, error) {
+	dec, sep := ".", ","
+	if opts.DecimalComma {
+		dec, sep = ",", "."
+	}
+
+	num, frac := str, ""
+	if i := strings.LastIndex(str, dec); i >= 0 {
+		num, frac = str[:i], "."+str[i+1:]
+	}
+
+	sign := ""
+	if strings.HasPrefix(num, "-") || strings.HasPrefix(num, "+") {
+		sign, num = num[:1], num[1:]
+	}
+
+	num = strings.NewReplacer(" ", sep, "\u00a0", sep).Replace(num)
+	groups := strings.Split(num, sep)
+	if len(groups) > 1 {
+		last := groups[len(groups)-1]
+		if frac == "" && len(last) > 0 && len(last) < 3 {
+			return 0, fmt.Errorf("amount %q doesn't use decimal mark %q", str, dec)
+		}
+		for i, g := range groups {
+			if len(g) > 3 || len(g) == 0 || (i > 0 && len(g) != 3) {
+				return 0, fmt.Errorf("invalid thousands separators in amount %q", str)
+			}
+		}
+	}
+
+	return strconv.ParseFloat(sign+strings.Join(groups, "")+frac, 64)
+}
+
+func itoaNonZero(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func (opts *CSVOptions) field(txn *Transaction, col CSVColumn) string {
+	switch col {
+	case CSVID:
+		return itoaNonZero(txn.ID)
+	case CSVDate:
+		return txn.DueDate.Format(opts.dateFormat())
+	case CSVAmount:
+		if txn.IsDebit() {
+			return opts.formatAmount(-txn.Amount)
+		}
+		return opts.formatAmount(txn.Amount)
+	case CSVCurrency:
+		return txn.Currency.String()
+	case CSVType:
+		return strconv.Itoa(txn.Type)
+	case CSVName:
+		return txn.Recipient.Name
+	case CSVAccount:
+		if txn.Recipient.AccountNum == 0 {
+			return ""
+		}
+		acc := Account{txn.Recipient.AccountNumPrefix, txn.Recipient.AccountNum, txn.Recipient.BankCode}
+		return acc.String()
+	case CSVBankCode:
+		if txn.Recipient.BankCode == 0 {
+			return ""
+		}
+		return strconv.Itoa(txn.Recipient.BankCode)
+	case CSVVS:
+		return itoaNonZero(txn.VS)
+	case CSVKS:
+		return itoaNonZero(txn.KS)
+	case CSVSS:
+		return itoaNonZero(txn.SS)
+	}
+	return ""
+}
+
+// WriteCSV writes statement transactions as CSV with a header row
+func (s *Statement) WriteCSV(wr io.Writer, opts CSVOptions) error {
+	cols := opts.Columns
+	if len(cols) == 0 {
+		cols = DefaultCSVColumns
+	}
+
+	if opts.BOM {
+		if _, err := io.WriteString(wr, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(wr)
+	cw.Comma = opts.delimiter()
+
+	row := make([]string, len(cols))
+	for i, col := range cols {
+		row[i] = string(col)
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+
+	var balances []RunningBalance
+	for _, col := range cols {
+		if col == CSVBalance {
+			balances = s.RunningBalances()
+		}
+	}
+
+	for j, txn := range s.Transactions {
+		for i, col := range cols {
+			if col == CSVBalance {
+				row[i] = opts.formatAmount(balances[j].Balance)
+			} else {
+				row[i] = opts.field(txn, col)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// CSVMapping describes a bank CSV export
+type CSVMapping struct {
+	CSVOptions
+	// Headers maps attributes to header names of the CSV, column names
+	// of DefaultCSVColumns are used if empty
+	Headers map[CSVColumn]string
+	// SkipRows is the number of rows preceding the header
+	SkipRows int
+	// Account is the owner account of the statement
+	Account Account
+}
+
+func (m *CSVMapping) setField(txn *Transaction, col CSVColumn, val string) error {
+	var err error
+
+	switch col {
+	case CSVID:
+		txn.ID, err = strconv.Atoi(val)
+	case CSVDate:
+		txn.DueDate, err = time.Parse(m.dateFormat(), val)
+	case CSVAmount:
+		txn.Amount, err = m.parseAmount(val)
+		if txn.Type == 0 {
+			txn.Type = TypeCredit
+			if txn.Amount < 0 {
+				txn.Type = TypeDebit
+			}
+		}
+		if txn.Amount < 0 {
+			txn.Amount = -txn.Amount
+		}
+	case CSVCurrency:
+		txn.Currency = currency.FromString(val)
+	case CSVType:
+		txn.Type, err = strconv.Atoi(val)
+	case CSVName:
+		txn.Recipient.Name = val
+	case CSVAccount:
+		var acc Account
+		if acc, err = ParseAccount(val); err == nil {
+			txn.Recipient.AccountNumPrefix = acc.Prefix
+			txn.Recipient.AccountNum = acc.Number
+			if acc.BankCode != 0 {
+				txn.Recipient.BankCode = acc.BankCode
+			}
+		}
+	case CSVBankCode:
+		txn.Recipient.BankCode, err = strconv.Atoi(val)
+	case CSVVS:
+		txn.VS, err = strconv.Atoi(val)
+	case CSVKS:
+		txn.KS, err = strconv.Atoi(val)
+	case CSVSS:
+		txn.SS, err = strconv.Atoi(val)
+	}
+
+	return err
+}
+
+// FromCSV reads transactions of a bank CSV export described by the mapping.
+// Statement dates and sums are computed from the transactions, balances are left zero.
+func FromCSV(rdr io.Reader, m CSVMapping) (*Statement, error) {
+	cr := csv.NewReader(rdr)
+	cr.Comma = m.delimiter()
+	cr.FieldsPerRecord = -1
+
+	for i := 0; i < m.SkipRows; i++ {
+		if _, err := cr.Read(); err != nil {
+			return nil, newErr("unable to skip CSV row %d: %v", i+1, err)
+		}
+	}
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, newErr("unable to read CSV header: %v", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+
+	names := m.Headers
+	if _, ok := names[CSVBalance]; ok {
+		return nil, newErr("CSV column %s is written only and can't be read", CSVBalance)
+	}
+	if len(names) == 0 {
+		names = map[CSVColumn]string{}
+		for _, col := range DefaultCSVColumns {
+			names[col] = string(col)
+		}
+	}
+
+	// column index for each mapped attribute
+	type mapped struct {
+		col CSVColumn
+		idx int
+	}
+	var cols []mapped
+	for _, col := range csvReadColumns {
+		name, ok := names[col]
+		if !ok {
+			continue
+		}
+
+		idx := -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, newErr("CSV column %q for %s not found", name, col)
+		}
+		cols = append(cols, mapped{col, idx})
+	}
+
+	s := new(Statement)
+	s.Info.AccountNumber = m.Account.fullNumber()
+	s.Info.BankCode = m.Account.BankCode
+	s.Transactions = []*Transaction{}
+
+	for line := m.SkipRows + 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, newErr("unable to read CSV row %d: %v", line, err)
+		}
+
+		txn := &Transaction{OwnerAccountNumber: s.Info.AccountNumber}
+		for _, c := range cols {
+			if c.idx >= len(row) {
+				continue
+			}
+			val := strings.TrimSpace(row[c.idx])
+			if val == "" {
+				continue
+			}
+			if err := m.setField(txn, c.col, val); err != nil {
+				return nil, newErr("invalid %s %q on CSV row %d: %v", c.col, val, line, err)
+			}
+		}
+
+		s.addTransaction(txn)
+	}
+
+	return s, nil
+}
+
+// addTransaction appends the transaction updating statement dates and sums
+func (s *Statement) addTransaction(txn *Transaction) {
+	if s.Info.StartDate.IsZero() || txn.DueDate.Before(s.Info.StartDate) {
+		s.Info.StartDate = txn.DueDate
+	}
+	if txn.DueDate.After(s.Info.EndDate) {
+		s.Info.EndDate = txn.DueDate
+	}
+
+	s.addSums(txn)
+	s.Transactions = append(s.Transactions, txn)
+}
+
+// addSums adds the transaction amount to statement income or expense sum
+func (s *Statement) addSums(txn *Transaction) {
+	if txn.IsDebit() {
+		s.Info.ExpenseSum = roundAmount(s.Info.ExpenseSum + txn.Amount)
+	} else {
+		s.Info.IncomeSum = roundAmount(s.Info.IncomeSum + txn.Amount)
+	}
+}
@@ -0,0 +1,107 @@
+package abo
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+func TestStatementCSV(t *testing.T) {
+	rdr, err := os.Open("./test/fio.gpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromReader(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CSVOptions{Delimiter: ';', DecimalComma: true, DateFormat: "2.1.2006", BOM: true}
+
+	buff := new(bytes.Buffer)
+	if err := stmt.WriteCSV(buff, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buff.String(), "\ufeffid;date;amount;") || !strings.Contains(buff.String(), ";24.9.2018;-1234,56;CZK;1;CEZ;7770227/0100;") {
+		t.Fatalf("unexpected CSV\n%s", buff.String())
+	}
+
+	back, err := FromCSV(buff, CSVMapping{CSVOptions: opts, Account: accountFromNumber(stmt.Info.AccountNumber)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Transactions) != len(stmt.Transactions) || *back.Transactions[0] != *stmt.Transactions[0] {
+		t.Fatalf("transaction mismatch\n%v\n%v", stmt.Transactions[0], back.Transactions[0])
+	}
+}
+
+func TestFromCSVMapping(t *testing.T) {
+	data := `"Výpis z účtu 2600113745/2010"
+"ID pohybu";"Datum";"Objem";"Měna";"Protiúčet";"Kód banky";"Název protiúčtu";"VS"
+"27022700166";"18.09.2024";"1 234,56";"CZK";"7022700166";"0100";"CEZ";"1446556401"
+"27022700167";"18.09.2024";"-3 955,14";"CZK";"19-2000145399";"0800";"Dodavatel";""
+`
+	m := CSVMapping{
+		CSVOptions: CSVOptions{Delimiter: ';', DecimalComma: true, DateFormat: "02.01.2006"},
+		Headers: map[CSVColumn]string{
+			CSVID: "ID pohybu", CSVDate: "Datum", CSVAmount: "Objem", CSVCurrency: "Měna",
+			CSVAccount: "Protiúčet", CSVBankCode: "Kód banky", CSVName: "Název protiúčtu", CSVVS: "VS",
+		},
+		SkipRows: 1,
+		Account:  Account{Number: 2600113745, BankCode: 2010},
+	}
+
+	stmt, err := FromCSV(strings.NewReader(data), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stmt.Info.AccountNumber != 2600113745 || stmt.Info.IncomeSum != 1234.56 || stmt.Info.ExpenseSum != 3955.14 {
+		t.Fatal("bad statement info")
+	}
+	if len(stmt.Transactions) != 2 {
+		t.Fatal("bad number of transactions")
+	}
+
+	tr := stmt.Transactions[1]
+	if tr.Type != TypeDebit || tr.Amount != 3955.14 || tr.Currency != currency.CZK || tr.Recipient.AccountNumPrefix != 19 || tr.Recipient.BankCode != 800 {
+		t.Fatalf("bad transaction %v", tr)
+	}
+}
+
+func TestCSVOptionsParseAmount(t *testing.T) {
+	for _, tc := range []struct {
+		opts CSVOptions
+		str  string
+		exp  float64
+	}{
+		{CSVOptions{}, "1,234.56", 1234.56},
+		{CSVOptions{}, "-1 234.5", -1234.5},
+		{CSVOptions{DecimalComma: true}, "1.234,56", 1234.56},
+		{CSVOptions{DecimalComma: true}, "-1 234,5", -1234.5},
+	} {
+		if amount, err := tc.opts.parseAmount(tc.str); err != nil || amount != tc.exp {
+			t.Fatalf("bad amount of %q: %v %v", tc.str, amount, err)
+		}
+	}
+
+	// other decimal mark than configured
+	for _, tc := range []struct {
+		opts CSVOptions
+		str  string
+	}{
+		{CSVOptions{}, "1234,56"},
+		{CSVOptions{}, "-3 955,1"},
+		{CSVOptions{DecimalComma: true}, "1.5"},
+		{CSVOptions{DecimalComma: true}, "1234.56"},
+		{CSVOptions{}, "12,34,567.00"},
+	} {
+		if amount, err := tc.opts.parseAmount(tc.str); err == nil {
+			t.Fatalf("amount %q accepted as %v", tc.str, amount)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package currency
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // Based on https://github.com/rmg/eagle/blob/master/Godeps/_workspace/src/github.com/rmg/iso4217/constants.go
 // License of this file only is MIT
@@ -381,6 +384,32 @@ const (
 	XXX     = Currency(999)
 )
 
+// MarshalText returns ISO 4217 code of the currency, empty for Unknown
+func (c Currency) MarshalText() ([]byte, error) {
+	if c == Unknown {
+		return []byte{}, nil
+	}
+	nm, ok := names[uint16(c)]
+	if !ok {
+		return nil, fmt.Errorf("currency: unknown currency %d", uint16(c))
+	}
+	return []byte(nm), nil
+}
+
+// UnmarshalText parses ISO 4217 code of the currency, empty is Unknown
+func (c *Currency) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = Unknown
+		return nil
+	}
+	cur := FromString(string(text))
+	if cur == Unknown {
+		return fmt.Errorf("currency: unknown currency code %q", text)
+	}
+	*c = cur
+	return nil
+}
+
 // FromString returns currency representing
 // a string identifier or Unknown for invalid code
 func FromString(code string) Currency {
@@ -0,0 +1,210 @@
+package abo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileOptions controls writing of order files
+type FileOptions struct {
+	// NoOverwrite refuses to replace an existing file
+	NoOverwrite bool
+	// Checksum writes a sidecar <path>.sha256 file in sha256sum format
+	Checksum bool
+	// Perm is the permission of the written files, 0600 if zero
+	Perm os.FileMode
+}
+
+// FileError describes a failure while writing a file
+type FileError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("abo: unable to %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// pendingFile is a written and synced temporary file waiting to be installed at its path
+type pendingFile struct {
+	path    string
+	tmp     string
+	perm    os.FileMode
+	backup  string // hard link to the replaced file
+	created bool   // path didn't exist before install
+}
+
+// writeTemp writes the content to a temporary file in the directory of path.
+// It returns SHA-256 of the content.
+func writeTemp(path string, perm os.FileMode, write func(io.Writer) error) (*pendingFile, []byte, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return nil, nil, &FileError{"create", path, err}
+	}
+	pf := &pendingFile{path: path, tmp: tmp.Name(), perm: perm}
+
+	hash := sha256.New()
+	err = write(io.MultiWriter(tmp, hash))
+	if err != nil {
+		err = &FileError{"write", path, err}
+	} else if err = tmp.Chmod(perm); err != nil {
+		err = &FileError{"chmod", path, err}
+	} else if err = tmp.Sync(); err != nil {
+		err = &FileError{"sync", path, err}
+	}
+	if cerr := tmp.Close(); err == nil && cerr != nil {
+		err = &FileError{"close", path, cerr}
+	}
+	if err != nil {
+		os.Remove(pf.tmp) //nolint:gosec
+		return nil, nil, err
+	}
+
+	return pf, hash.Sum(nil), nil
+}
+
+// install moves the temporary file to its path. With noOverwrite it fails if the path
+// exists, using a hard link or an exclusive create on filesystems without hard links.
+// A replaced file is kept as a hard link for rollback where hard links are supported.
+func (pf *pendingFile) install(noOverwrite bool) error {
+	if noOverwrite {
+		err := os.Link(pf.tmp, pf.path)
+		if err != nil && !errors.Is(err, os.ErrExist) {
+			// no hard links, claim the path by exclusive create and rename over it
+			var f *os.File
+			if f, err = os.OpenFile(pf.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, pf.perm); err == nil {
+				f.Close() //nolint:gosec
+				if err = os.Rename(pf.tmp, pf.path); err != nil {
+					os.Remove(pf.path) //nolint:gosec
+				}
+			}
+		}
+		if err != nil {
+			return &FileError{"create", pf.path, err}
+		}
+		pf.created = true
+		return nil
+	}
+
+	if _, err := os.Lstat(pf.path); err != nil {
+		pf.created = true
+	} else if backup, err := os.CreateTemp(filepath.Dir(pf.path), "."+filepath.Base(pf.path)+".bak*"); err == nil {
+		backup.Close()           //nolint:gosec
+		os.Remove(backup.Name()) //nolint:gosec
+		if os.Link(pf.path, backup.Name()) == nil {
+			pf.backup = backup.Name()
+		}
+	}
+
+	if err := os.Rename(pf.tmp, pf.path); err != nil {
+		pf.finish()
+		return &FileError{"rename", pf.path, err}
+	}
+	return nil
+}
+
+// rollback restores the state before install where possible
+func (pf *pendingFile) rollback() {
+	if pf.backup != "" {
+		os.Rename(pf.backup, pf.path) //nolint:gosec
+		pf.backup = ""
+	} else if pf.created {
+		os.Remove(pf.path) //nolint:gosec
+	}
+}
+
+// finish removes the temporary file and the backup
+func (pf *pendingFile) finish() {
+	os.Remove(pf.tmp) //nolint:gosec
+	if pf.backup != "" {
+		os.Remove(pf.backup) //nolint:gosec
+	}
+}
+
+// syncDir makes a rename durable where supported
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()  //nolint:gosec,not supported everywhere
+	d.Close() //nolint:gosec
+}
+
+// WriteToFileOpts atomically writes the order to a .kpc file.
+// A partially written file never appears at the path. With Checksum the order
+// and its .sha256 file are both written before either is installed, and the order
+// file is rolled back if the checksum file can't be installed. Restoring a replaced
+// order file requires a filesystem with hard links.
+func (or *Order) WriteToFileOpts(path string, opts FileOptions) error {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0600
+	}
+	sumPath := path + ".sha256"
+
+	if opts.NoOverwrite {
+		for _, p := range []string{path, sumPath} {
+			if p == sumPath && !opts.Checksum {
+				continue
+			}
+			if _, err := os.Lstat(p); err == nil {
+				return &FileError{"create", p, os.ErrExist}
+			}
+		}
+	}
+
+	files := make([]*pendingFile, 0, 2)
+	defer func() {
+		for _, pf := range files {
+			pf.finish()
+		}
+	}()
+
+	pf, sum, err := writeTemp(path, perm, or.Write)
+	if err != nil {
+		return err
+	}
+	files = append(files, pf)
+
+	if opts.Checksum {
+		line := hex.EncodeToString(sum) + "  " + filepath.Base(path) + "\n"
+		pf, _, err := writeTemp(sumPath, perm, func(wr io.Writer) error {
+			_, err := io.WriteString(wr, line)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		files = append(files, pf)
+	}
+
+	for i, pf := range files {
+		if err := pf.install(opts.NoOverwrite); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				files[j].rollback()
+			}
+			return err
+		}
+	}
+
+	syncDir(filepath.Dir(path))
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package abo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrderWriteToFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "order.kpc")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 4096)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := new(Order)
+	o.CreationDate = time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+	o.AddGroup(0, 2101135843, o.CreationDate).AddItemSimple(0, 1900133399, 2010, 1.23, 1, "")
+
+	if err := o.WriteToFileOpts(path, FileOptions{Checksum: true, Perm: 0640}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "x") || !strings.HasSuffix(string(data), "5 +\n") {
+		t.Fatal("old file content not replaced")
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != 0640 {
+		t.Fatalf("bad permissions %v", st.Mode().Perm())
+	}
+
+	sum, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(sum), "  order.kpc\n") || len(sum) != 64+2+9+1 {
+		t.Fatalf("bad checksum file %q", sum)
+	}
+
+	err = o.WriteToFileOpts(path, FileOptions{NoOverwrite: true})
+	var ferr *FileError
+	if !errors.As(err, &ferr) || !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected FileError with ErrExist, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatal("temporary files left behind")
+	}
+}
+
+func TestOrderWriteToFileChecksumAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "order.kpc")
+
+	o := new(Order)
+	o.CreationDate = time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+	o.AddGroup(0, 2101135843, o.CreationDate).AddItemSimple(0, 1900133399, 2010, 1.23, 1, "")
+
+	// existing checksum file prevents writing the order
+	if err := os.WriteFile(path+".sha256", []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.WriteToFileOpts(path, FileOptions{NoOverwrite: true, Checksum: true}); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("order written without checksum")
+	}
+
+	// checksum file which can't be replaced rolls back the replaced order
+	if err := os.Remove(path + ".sha256"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path+".sha256", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".sha256/x", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.WriteToFileOpts(path, FileOptions{Checksum: true}); err == nil {
+		t.Fatal("expected checksum write error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Fatalf("order not rolled back: %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatal("temporary files left behind")
+	}
+}
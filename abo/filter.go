@@ -0,0 +1,235 @@
+package abo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// Filter selects transactions
+type Filter func(txn *Transaction) bool
+
+// FilterDate selects transactions from..to inclusive by calendar day, zero bound is open
+func FilterDate(from, to time.Time) Filter {
+	return func(txn *Transaction) bool {
+		d := CalendarDay(txn.DueDate)
+		return (from.IsZero() || !d.Before(CalendarDay(from))) && (to.IsZero() || !d.After(CalendarDay(to)))
+	}
+}
+
+// FilterAmount selects transactions with amount from..to inclusive regardless of direction,
+// zero to is unbounded
+func FilterAmount(from, to float64) Filter {
+	return func(txn *Transaction) bool {
+		amount := ToHalere(txn.Amount)
+		return amount >= ToHalere(from) && (to == 0 || amount <= ToHalere(to))
+	}
+}
+
+// FilterDebits selects transactions decreasing the balance
+func FilterDebits() Filter {
+	return func(txn *Transaction) bool {
+		return txn.IsDebit()
+	}
+}
+
+// FilterCredits selects transactions increasing the balance
+func FilterCredits() Filter {
+	return func(txn *Transaction) bool {
+		return !txn.IsDebit()
+	}
+}
+
+// FilterType selects transactions of the types
+func FilterType(types ...int) Filter {
+	return func(txn *Transaction) bool {
+		for _, typ := range types {
+			if txn.Type == typ {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterCounterparty selects transactions of the counterparty account,
+// zero bank code matches any bank
+func FilterCounterparty(acc Account) Filter {
+	return func(txn *Transaction) bool {
+		return txn.Recipient.AccountNumPrefix == acc.Prefix && txn.Recipient.AccountNum == acc.Number &&
+			(acc.BankCode == 0 || txn.Recipient.BankCode == acc.BankCode)
+	}
+}
+
+// FilterName selects transactions with counterparty name containing the substring, ignoring case
+func FilterName(substr string) Filter {
+	substr = strings.ToLower(substr)
+	return func(txn *Transaction) bool {
+		return strings.Contains(strings.ToLower(txn.Recipient.Name), substr)
+	}
+}
+
+// FilterVS selects transactions with the variable symbol
+func FilterVS(vs int) Filter {
+	return func(txn *Transaction) bool {
+		return txn.VS == vs
+	}
+}
+
+// FilterKS selects transactions with the constant symbol
+func FilterKS(ks int) Filter {
+	return func(txn *Transaction) bool {
+		return txn.KS == ks
+	}
+}
+
+// FilterSS selects transactions with the specific symbol
+func FilterSS(ss int) Filter {
+	return func(txn *Transaction) bool {
+		return txn.SS == ss
+	}
+}
+
+// FilterCurrency selects transactions in the currency
+func FilterCurrency(cur currency.Currency) Filter {
+	return func(txn *Transaction) bool {
+		return txn.Currency == cur
+	}
+}
+
+// FilterAny selects transactions matching any of the filters
+func FilterAny(filters ...Filter) Filter {
+	return func(txn *Transaction) bool {
+		for _, f := range filters {
+			if f(txn) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterNot selects transactions not matching the filter
+func FilterNot(f Filter) Filter {
+	return func(txn *Transaction) bool {
+		return !f(txn)
+	}
+}
+
+// view returns a copy of the statement with the transactions and recomputed sums.
+// Balances of the statement don't apply to a subset of its transactions, so the view
+// opens at zero and closes at its net turnover.
+func (s *Statement) view(txns []*Transaction) *Statement {
+	v := &Statement{Info: s.Info, Transactions: txns}
+
+	v.Info.IncomeSum, v.Info.ExpenseSum = 0, 0
+	for _, txn := range txns {
+		v.addSums(txn)
+	}
+	v.Info.OpeningBalance = 0
+	v.Info.ClosingBalance = roundAmount(v.Info.IncomeSum - v.Info.ExpenseSum)
+
+	return v
+}
+
+// Filter returns a statement view of transactions matching all filters.
+// The view shares transactions with the statement and income and expense sums
+// are recomputed. Opening balance of the view is zero and closing balance is
+// the net turnover, so running and daily balances sum the view's transactions.
+// Other statement information, including dates, is kept.
+func (s *Statement) Filter(filters ...Filter) *Statement {
+	txns := []*Transaction{}
+	for _, txn := range s.Transactions {
+		ok := true
+		for _, f := range filters {
+			if !f(txn) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			txns = append(txns, txn)
+		}
+	}
+
+	return s.view(txns)
+}
+
+// ByDate orders transactions by date
+func ByDate(a, b *Transaction) bool {
+	return a.DueDate.Before(b.DueDate)
+}
+
+// ByAmount orders transactions by signed amount, debits first
+func ByAmount(a, b *Transaction) bool {
+	return ToHalere(a.signedAmount()) < ToHalere(b.signedAmount())
+}
+
+// Sort returns a statement view with transactions stably sorted by less
+func (s *Statement) Sort(less func(a, b *Transaction) bool) *Statement {
+	txns := append([]*Transaction{}, s.Transactions...)
+	sort.SliceStable(txns, func(i, j int) bool {
+		return less(txns[i], txns[j])
+	})
+
+	return s.view(txns)
+}
+
+// KeyCounterparty groups transactions by counterparty account, or name if account is unknown
+func KeyCounterparty(txn *Transaction) string {
+	if txn.Recipient.AccountNum == 0 {
+		return txn.Recipient.Name
+	}
+	return Account{txn.Recipient.AccountNumPrefix, txn.Recipient.AccountNum, txn.Recipient.BankCode}.String()
+}
+
+// KeyMonth groups transactions by month as YYYY-MM
+func KeyMonth(txn *Transaction) string {
+	return txn.DueDate.Format("2006-01")
+}
+
+// KeyKS groups transactions by constant symbol
+func KeyKS(txn *Transaction) string {
+	return strconv.Itoa(txn.KS)
+}
+
+// StatementGroup is a statement view of transactions with the same key
+type StatementGroup struct {
+	Key       string
+	Statement *Statement
+}
+
+// GroupBy splits transactions into statement views by key, ordered by key.
+// Integer keys are ordered numerically and before other keys.
+// Transactions keep their order within a group.
+func (s *Statement) GroupBy(key func(txn *Transaction) string) []StatementGroup {
+	groups := make(map[string][]*Transaction)
+	var keys []string
+	for _, txn := range s.Transactions {
+		k := key(txn)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], txn)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.ParseInt(keys[i], 10, 64)
+		b, errB := strconv.ParseInt(keys[j], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return keys[i] < keys[j]
+	})
+
+	res := make([]StatementGroup, len(keys))
+	for i, k := range keys {
+		res[i] = StatementGroup{k, s.view(groups[k])}
+	}
+	return res
+}
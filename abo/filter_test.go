@@ -0,0 +1,92 @@
+package abo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+func readFilterStatement(t *testing.T) *Statement {
+	f, err := os.Open("test/filter.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := FromCSV(f, CSVMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Info.AccountNumber = 2600113745
+	s.Info.OpeningBalance = 5000
+	s.Info.ClosingBalance = 5800
+	return s
+}
+
+func TestFilter(t *testing.T) {
+	s := readFilterStatement(t)
+	day := s.Transactions[0].DueDate
+
+	v := s.Filter(FilterDate(day.AddDate(0, 0, 1), time.Time{}), FilterAmount(50, 500))
+	if len(v.Transactions) != 2 || v.Info.ExpenseSum != 300 || v.Info.IncomeSum != 0 || v.Info.AccountNumber != 2600113745 {
+		t.Fatalf("unexpected view %+v", v.Info)
+	}
+
+	if v = s.Filter(FilterName("čez"), FilterCredits()); len(v.Transactions) != 1 || v.Info.IncomeSum != 100 {
+		t.Fatalf("unexpected view %+v", v.Info)
+	}
+	if v = s.Filter(FilterCounterparty(Account{Number: 7770227}), FilterDebits(), FilterKS(308)); len(v.Transactions) != 1 {
+		t.Fatalf("unexpected view %+v", v.Info)
+	}
+	if v = s.Filter(FilterAny(FilterKS(8), FilterType(TypeDebit)), FilterNot(FilterVS(1))); len(v.Transactions) != 3 {
+		t.Fatalf("unexpected view %+v", v.Info)
+	}
+	if v = s.Filter(FilterCurrency(currency.EUR)); len(v.Transactions) != 0 || v.Transactions == nil {
+		t.Fatal("EUR transactions found")
+	}
+	if len(s.Transactions) != 4 || s.Info.IncomeSum != 1100 || s.Info.OpeningBalance != 5000 {
+		t.Fatal("statement modified")
+	}
+
+	// balances of the view sum its transactions only
+	v = s.Filter(FilterDebits())
+	if v.Info.OpeningBalance != 0 || v.Info.ClosingBalance != -300 {
+		t.Fatalf("unexpected balances %+v", v.Info)
+	}
+	if rb := v.RunningBalances(); rb[len(rb)-1].Balance != v.Info.ClosingBalance {
+		t.Fatalf("unexpected running balances %+v", rb)
+	}
+	if db := v.DailyBalances(); len(db) != 3 || db[0].Balance != 0 || db[2].Balance != v.Info.ClosingBalance {
+		t.Fatalf("unexpected daily balances %+v", db)
+	}
+}
+
+func TestSortGroup(t *testing.T) {
+	s := readFilterStatement(t)
+
+	v := s.Sort(ByAmount)
+	if v.Transactions[0].Amount != 250 || v.Transactions[3].Amount != 1000 || s.Transactions[0].Amount != 100 {
+		t.Fatal("unexpected order")
+	}
+	if v = v.Sort(ByDate); v.Transactions[0].Amount != 100 || v.Transactions[1].Amount != 250 {
+		t.Fatal("unexpected order")
+	}
+
+	groups := s.GroupBy(KeyCounterparty)
+	if len(groups) != 3 || groups[2].Key != "7770227/0100" || len(groups[2].Statement.Transactions) != 2 ||
+		groups[2].Statement.Info.IncomeSum != 100 || groups[2].Statement.Info.ExpenseSum != 50 {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	groups = s.GroupBy(KeyMonth)
+	if len(groups) != 2 || groups[0].Key != "2024-09" || groups[1].Key != "2024-10" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	groups = s.GroupBy(KeyKS)
+	if len(groups) != 3 || groups[0].Key != "0" || groups[1].Key != "8" || groups[2].Key != "308" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+}
@@ -0,0 +1,116 @@
+package abo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// FlexiOptions controls export of statements to ABRA Flexi XML
+type FlexiOptions struct {
+	// BankAccount is the code of the bank account in Flexi, default account if empty
+	BankAccount string
+	// DocumentType is the code of the bank document type, "STANDARD" if empty
+	DocumentType string
+	// IDPrefix is the system part of external document IDs (ext:IDPrefix:ID), "ABO" if empty
+	IDPrefix string
+}
+
+// ABRA Flexi XML writing structures
+
+type flexiWinstrom struct {
+	XMLName xml.Name    `xml:"winstrom"`
+	Version string      `xml:"version,attr"`
+	Banka   []flexiBank `xml:"banka"`
+}
+
+type flexiBank struct {
+	ID         string `xml:"id"`
+	TypDokl    string `xml:"typDokl"`
+	Banka      string `xml:"banka,omitempty"`
+	TypPohybuK string `xml:"typPohybuK"`
+	DatVyst    string `xml:"datVyst"`
+	VarSym     string `xml:"varSym,omitempty"`
+	KonSym     string `xml:"konSym,omitempty"`
+	SpecSym    string `xml:"specSym,omitempty"`
+	Popis      string `xml:"popis"`
+	NazFirmy   string `xml:"nazFirmy,omitempty"`
+	Buc        string `xml:"buc,omitempty"`
+	SmerKod    string `xml:"smerKod,omitempty"`
+	Mena       string `xml:"mena"`
+	SumOsv     string `xml:"sumOsv,omitempty"`
+	SumOsvMen  string `xml:"sumOsvMen,omitempty"`
+	BezPolozek bool   `xml:"bezPolozek"`
+}
+
+func (s *Statement) flexiBank(idx int, txn *Transaction, ccy currency.Currency, opts *FlexiOptions) flexiBank {
+	if txn.Currency != currency.Unknown {
+		ccy = txn.Currency
+	}
+
+	b := flexiBank{
+		ID:         "ext:" + orDefault(opts.IDPrefix, "ABO") + ":" + s.fitID(idx, txn),
+		TypDokl:    "code:" + orDefault(opts.DocumentType, "STANDARD"),
+		TypPohybuK: "typPohybu.prijem",
+		DatVyst:    txn.DueDate.Format("2006-01-02"),
+		Popis:      truncRunes(txn.payee(), 255),
+		NazFirmy:   truncRunes(txn.Recipient.Name, 255),
+		Mena:       "code:" + ccy.String(),
+		BezPolozek: true,
+	}
+	if opts.BankAccount != "" {
+		b.Banka = "code:" + opts.BankAccount
+	}
+	if txn.IsDebit() {
+		b.TypPohybuK = "typPohybu.vydej"
+	}
+	if txn.VS != 0 {
+		b.VarSym = strconv.Itoa(txn.VS)
+	}
+	if txn.KS != 0 {
+		b.KonSym = fmt.Sprintf("code:%04d", txn.KS)
+	}
+	if txn.SS != 0 {
+		b.SpecSym = strconv.Itoa(txn.SS)
+	}
+	if txn.Recipient.AccountNum != 0 {
+		b.Buc = Account{Prefix: txn.Recipient.AccountNumPrefix, Number: txn.Recipient.AccountNum}.String()
+		if txn.Recipient.BankCode != 0 {
+			b.SmerKod = fmt.Sprintf("code:%04d", txn.Recipient.BankCode)
+		}
+	}
+	if ccy == currency.CZK {
+		b.SumOsv = formatAmount(txn.Amount)
+	} else {
+		b.SumOsvMen = formatAmount(txn.Amount)
+	}
+
+	return b
+}
+
+// WriteFlexi writes the statement as ABRA Flexi XML of bank documents (banka) without items.
+// Documents have external IDs so repeated imports update instead of duplicating them.
+func (s *Statement) WriteFlexi(wr io.Writer, opts FlexiOptions) error {
+	ccy := s.currency()
+	doc := flexiWinstrom{Version: "1.0"}
+
+	for i, txn := range s.Transactions {
+		doc.Banka = append(doc.Banka, s.flexiBank(i, txn, ccy, &opts))
+	}
+
+	if _, err := io.WriteString(wr, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(wr)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return newErr("unable to encode Flexi XML: %w", err)
+	}
+
+	_, err := io.WriteString(wr, "\n")
+	return err
+}
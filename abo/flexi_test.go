@@ -0,0 +1,45 @@
+package abo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestStatementWriteFlexi(t *testing.T) {
+	s := readTestStatement(t)
+
+	buff := new(bytes.Buffer)
+	if err := s.WriteFlexi(buff, FlexiOptions{BankAccount: "BANKA"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc flexiWinstrom
+	if err := xml.Unmarshal(buff.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "1.0" || len(doc.Banka) != len(s.Transactions) {
+		t.Fatalf("unexpected document %v", doc)
+	}
+
+	exp := flexiBank{
+		ID:         "ext:ABO:16659818503",
+		TypDokl:    "code:STANDARD",
+		Banka:      "code:BANKA",
+		TypPohybuK: "typPohybu.vydej",
+		DatVyst:    "2018-09-24",
+		VarSym:     "1446556401",
+		KonSym:     "code:0308",
+		SpecSym:    "7815392681",
+		Popis:      "CEZ",
+		NazFirmy:   "CEZ",
+		Buc:        "7770227",
+		SmerKod:    "code:0100",
+		Mena:       "code:CZK",
+		SumOsv:     "1234.56",
+		BezPolozek: true,
+	}
+	if doc.Banka[0] != exp {
+		t.Fatalf("bank document mismatch\n%v\n%v", exp, doc.Banka[0])
+	}
+}
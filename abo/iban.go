@@ -0,0 +1,87 @@
+package abo
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// normalizeIBAN removes spaces and converts IBAN to upper case
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ibanMod97 computes ISO 7064 mod 97-10 of IBAN with the first four characters moved to the end
+func ibanMod97(iban string) (int64, error) {
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return 0, newErr("invalid character %q in IBAN", r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return 0, newErr("invalid IBAN %s", iban)
+	}
+
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64(), nil
+}
+
+// ValidateIBAN checks the length and check digits of IBAN
+func ValidateIBAN(iban string) error {
+	iban = normalizeIBAN(iban)
+	if len(iban) < 15 || len(iban) > 34 {
+		return newErr("invalid IBAN length of %s", iban)
+	}
+
+	mod, err := ibanMod97(iban)
+	if err != nil {
+		return err
+	}
+	if mod != 1 {
+		return newErr("invalid IBAN check digits of %s", iban)
+	}
+
+	return nil
+}
+
+// IBAN returns Czech IBAN of the account
+func (acc Account) IBAN() string {
+	bban := fmt.Sprintf("%04d%06d%010d", acc.BankCode, acc.Prefix, acc.Number)
+
+	mod, _ := ibanMod97("CZ00" + bban) //nolint:gosec,always numeric
+	return fmt.Sprintf("CZ%02d%s", 98-mod, bban)
+}
+
+// AccountFromIBAN parses Czech IBAN into account number
+func AccountFromIBAN(iban string) (Account, error) {
+	iban = normalizeIBAN(iban)
+
+	if err := ValidateIBAN(iban); err != nil {
+		return Account{}, err
+	}
+	if len(iban) != 24 || !strings.HasPrefix(iban, "CZ") {
+		return Account{}, newErr("%s is not a Czech IBAN", iban)
+	}
+
+	var acc Account
+	var err error
+	if acc.BankCode, err = strconv.Atoi(iban[4:8]); err != nil {
+		return Account{}, newErr("invalid bank code in IBAN %s", iban)
+	}
+	if acc.Prefix, err = strconv.Atoi(iban[8:14]); err != nil {
+		return Account{}, newErr("invalid account prefix in IBAN %s", iban)
+	}
+	if acc.Number, err = strconv.Atoi(iban[14:]); err != nil {
+		return Account{}, newErr("invalid account number in IBAN %s", iban)
+	}
+
+	return acc, nil
+}
@@ -0,0 +1,29 @@
+package abo
+
+import "testing"
+
+func TestIBAN(t *testing.T) {
+	acc := Account{Prefix: 19, Number: 2000145399, BankCode: 800}
+
+	if iban := acc.IBAN(); iban != "CZ6508000000192000145399" {
+		t.Fatalf("bad IBAN %s", iban)
+	}
+
+	parsed, err := AccountFromIBAN("CZ65 0800 0000 1920 0014 5399")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != acc {
+		t.Fatalf("bad account %v", parsed)
+	}
+
+	if err := ValidateIBAN("DE89370400440532013000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateIBAN("CZ6608000000192000145399"); err == nil {
+		t.Fatal("expected check digit error")
+	}
+	if _, err := AccountFromIBAN("DE89370400440532013000"); err == nil {
+		t.Fatal("expected error for foreign IBAN")
+	}
+}
@@ -1,10 +1,13 @@
 package abo
 
 import (
+	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding/charmap"
 )
@@ -79,17 +82,98 @@ func newAboReader(rdr io.Reader) *reader {
 	return &reader{rdr}
 }
 
+// OverflowPolicy determines what happens with values not fitting their fixed-width fields
+type OverflowPolicy int
+
+const (
+	// OverflowFail makes the write fail with *OverflowError
+	OverflowFail OverflowPolicy = iota
+	// OverflowTruncate shortens the value and reports it as Truncation
+	OverflowTruncate
+)
+
+// OverflowError is returned when a value doesn't fit its fixed-width field
+type OverflowError struct {
+	Field string
+	Value string
+	Width int
+}
+
+func (e *OverflowError) Error() string {
+	if strings.HasPrefix(e.Value, "-") {
+		return fmt.Sprintf("negative value %s of field %s can't be written", e.Value, e.Field)
+	}
+	return fmt.Sprintf("value %q of field %s exceeds %d bytes", e.Value, e.Field, e.Width)
+}
+
+// Truncation reports a value shortened to fit its field
+type Truncation struct {
+	Field   string
+	Value   string
+	Written string
+	Width   int
+}
+
 type writer struct {
 	io.Writer
+	policy      OverflowPolicy
+	truncations []Truncation
+	textPolicy  TextPolicy
+	replacement string
+	textChanges []TextChange
 }
 
-func (wr *writer) WritePad(inBytes []byte, byteLen int, paddingByte byte, padLeft bool) error {
-	if len(inBytes) > byteLen {
-		// shortened data
-		_, err := wr.Write(inBytes[:byteLen])
-		return err
+// sanitize applies the text policy to a text field value
+func (wr *writer) sanitize(field, str string) (string, error) {
+	repl := wr.replacement
+	if repl == "" {
+		repl = "?"
+	}
+
+	out, err := sanitizeText(field, str, wr.textPolicy, repl)
+	if err != nil {
+		return "", err
 	}
 
+	if out != str {
+		wr.textChanges = append(wr.textChanges, TextChange{field, str, out})
+	}
+
+	return out, nil
+}
+
+// fit checks that the value fits the width as measured by size, truncating it
+// at a rune boundary if allowed by the policy. Overflows report the original value.
+func (wr *writer) fit(field, value string, byteLen int, size func(string) int) (string, error) {
+	if size(value) <= byteLen {
+		return value, nil
+	}
+
+	if wr.policy != OverflowTruncate {
+		return "", &OverflowError{field, value, byteLen}
+	}
+
+	written := value
+	for size(written) > byteLen {
+		_, n := utf8.DecodeLastRuneInString(written)
+		written = written[:len(written)-n]
+	}
+
+	wr.truncations = append(wr.truncations, Truncation{field, value, written, byteLen})
+	return written, nil
+}
+
+// byteSize measures plain strings written as they are
+func byteSize(str string) int {
+	return len(str)
+}
+
+// windows1250Size measures sanitized strings, encoded to one byte per rune
+func windows1250Size(str string) int {
+	return utf8.RuneCountInString(str)
+}
+
+func (wr *writer) WritePad(inBytes []byte, byteLen int, paddingByte byte, padLeft bool) error {
 	// prep padding
 	pad := make([]byte, byteLen-len(inBytes))
 	for i := range pad {
@@ -121,30 +205,79 @@ func (wr *writer) WritePad(inBytes []byte, byteLen int, paddingByte byte, padLef
 	return nil
 }
 
-func (wr *writer) WriteStr(str string, byteLen int) error {
+// WriteSep writes a literal separator or record marker
+func (wr *writer) WriteSep(sep string) error {
+	_, err := io.WriteString(wr, sep)
+	return err
+}
+
+func (wr *writer) WriteStr(field, str string, byteLen int) error {
+	str, err := wr.fit(field, str, byteLen, byteSize)
+	if err != nil {
+		return err
+	}
+
 	return wr.WritePad([]byte(str), byteLen, ' ', false)
 }
 
-func (wr *writer) WriteStrWindows1250(str string, byteLen int) error {
+func (wr *writer) WriteStrWindows1250(field, str string, byteLen int) error {
+	str, err := wr.sanitize(field, str)
+	if err != nil {
+		return err
+	}
+
+	str, err = wr.fit(field, str, byteLen, windows1250Size)
+	if err != nil {
+		return err
+	}
+
 	enc := charmap.Windows1250.NewEncoder()
 	out, err := enc.String(str)
 	if err != nil {
 		return err
 	}
 
-	return wr.WriteStr(out, byteLen)
+	return wr.WritePad([]byte(out), byteLen, ' ', false)
+}
+
+// WriteVarStrWindows1250 writes variable-length string of at most maxLen bytes
+func (wr *writer) WriteVarStrWindows1250(field, str string, maxLen int) error {
+	str, err := wr.sanitize(field, str)
+	if err != nil {
+		return err
+	}
+
+	str, err = wr.fit(field, str, maxLen, windows1250Size)
+	if err != nil {
+		return err
+	}
+
+	enc := charmap.Windows1250.NewEncoder()
+	out, err := enc.Bytes([]byte(str))
+	if err != nil {
+		return err
+	}
+
+	_, err = wr.Write(out)
+	return err
 }
 
-func (wr *writer) WriteInt(i int, byteLen int) error {
-	return wr.WritePad([]byte(strconv.Itoa(i)), byteLen, '0', true)
+// WriteInt writes zero-padded non-negative integer. Numbers are never truncated
+// regardless of the policy as that would change account numbers or amounts.
+func (wr *writer) WriteInt(field string, i int, byteLen int) error {
+	str := strconv.Itoa(i)
+	if i < 0 || len(str) > byteLen {
+		return &OverflowError{field, str, byteLen}
+	}
+	return wr.WritePad([]byte(str), byteLen, '0', true)
 }
 
-func (wr *writer) WriteMonetaryAmount(amount float64, byteLen int) error {
-	return wr.WriteInt(int(amount*100), byteLen)
+func (wr *writer) WriteMonetaryAmount(field string, amount float64, byteLen int) error {
+	return wr.WriteInt(field, int(math.Round(amount*100)), byteLen)
 }
 
-func (wr *writer) WriteTime(tm time.Time) error {
-	return wr.WriteStr(tm.Format(formatDDMMYY), 6)
+func (wr *writer) WriteTime(field string, tm time.Time) error {
+	return wr.WriteStr(field, tm.Format(formatDDMMYY), 6)
 }
 
 func (wr *writer) WriteLineEnd() error {
@@ -156,5 +289,5 @@ func newWriter(wr io.Writer) *writer {
 	if wr, isAlready := wr.(*writer); isAlready {
 		return wr
 	}
-	return &writer{wr}
+	return &writer{Writer: wr}
 }
@@ -0,0 +1,329 @@
+// Package isdoc reads Czech ISDOC e-invoices and turns their bank transfer
+// payment means into ABO payment order items.
+package isdoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3a/ago/abo"
+)
+
+func newErr(format string, args ...interface{}) error {
+	return fmt.Errorf("isdoc: "+format, args...)
+}
+
+// ErrNoPaymentMeans is reported for invoices without bank transfer payment details
+var ErrNoPaymentMeans = newErr("invoice has no bank transfer payment means")
+
+// Payment means codes of bank transfers
+const (
+	MeansCreditTransfer = 31
+	MeansBankAccount    = 42
+)
+
+// Payment is a single payment of an invoice
+type Payment struct {
+	Amount float64
+	// MeansCode is the ISDOC payment means code, 42 for payment to a bank account
+	MeansCode int
+	DueDate   time.Time
+	Account   abo.Account
+	IBAN      string
+	BIC       string
+	Symbols   abo.Symbols
+}
+
+// IsBankTransfer reports whether the payment is a transfer to a known account
+func (p *Payment) IsBankTransfer() bool {
+	return (p.MeansCode == MeansBankAccount || p.MeansCode == MeansCreditTransfer) &&
+		p.Account.Number != 0 && p.Account.BankCode != 0
+}
+
+// Invoice is the payment related part of an ISDOC invoice
+type Invoice struct {
+	ID        string
+	UUID      string
+	IssueDate time.Time
+	// Currency is the foreign currency of the invoice if set, the local currency otherwise
+	Currency string
+	// Supplier is the name of the payee
+	Supplier string
+	// Amount is the payable amount of the invoice
+	Amount   float64
+	Payments []Payment
+}
+
+// ISDOC reading structures, namespaces are ignored to support all 5.x and 6.x versions
+
+type xmlInvoice struct {
+	XMLName             xml.Name `xml:"Invoice"`
+	ID                  string
+	UUID                string
+	IssueDate           string
+	LocalCurrencyCode   string
+	ForeignCurrencyCode string
+	SupplierName        string `xml:"AccountingSupplierParty>Party>PartyName>Name"`
+	PayableAmount       string `xml:"LegalMonetaryTotal>PayableAmount"`
+	Payments            []struct {
+		PaidAmount       string
+		PaymentMeansCode string
+		Details          struct {
+			PaymentDueDate string
+			ID             string
+			BankCode       string
+			IBAN           string
+			BIC            string
+			VariableSymbol string
+			ConstantSymbol string
+			SpecificSymbol string
+		}
+	} `xml:"PaymentMeans>Payment"`
+}
+
+func parseDate(str string) (time.Time, error) {
+	if str = strings.TrimSpace(str); str == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", str)
+}
+
+func parseAmount(str string) (float64, error) {
+	if str = strings.TrimSpace(str); str == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(str, 64)
+}
+
+func parseSymbol(name, str string) (int, error) {
+	if str = strings.TrimSpace(str); str == "" {
+		return 0, nil
+	}
+	if len(str) > 10 {
+		return 0, newErr("%s %q is too long", name, str)
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < 0 {
+		return 0, newErr("%s %q is not numeric", name, str)
+	}
+	return val, nil
+}
+
+func (xp *xmlInvoice) invoice() (*Invoice, error) {
+	var err error
+	inv := &Invoice{
+		ID:       strings.TrimSpace(xp.ID),
+		UUID:     strings.TrimSpace(xp.UUID),
+		Currency: strings.TrimSpace(xp.LocalCurrencyCode),
+		Supplier: strings.TrimSpace(xp.SupplierName),
+	}
+	if ccy := strings.TrimSpace(xp.ForeignCurrencyCode); ccy != "" {
+		inv.Currency = ccy
+	}
+	if inv.IssueDate, err = parseDate(xp.IssueDate); err != nil {
+		return nil, newErr("invalid issue date: %w", err)
+	}
+	if inv.Amount, err = parseAmount(xp.PayableAmount); err != nil {
+		return nil, newErr("invalid payable amount: %w", err)
+	}
+
+	for _, xpay := range xp.Payments {
+		var p Payment
+		d := &xpay.Details
+
+		if p.Amount, err = parseAmount(xpay.PaidAmount); err != nil {
+			return nil, newErr("invalid paid amount: %w", err)
+		}
+		if p.MeansCode, err = strconv.Atoi(strings.TrimSpace(xpay.PaymentMeansCode)); err != nil {
+			return nil, newErr("invalid payment means code %q", xpay.PaymentMeansCode)
+		}
+		if p.DueDate, err = parseDate(d.PaymentDueDate); err != nil {
+			return nil, newErr("invalid payment due date: %w", err)
+		}
+		if p.Symbols.VS, err = parseSymbol("variable symbol", d.VariableSymbol); err != nil {
+			return nil, err
+		}
+		if p.Symbols.KS, err = parseSymbol("constant symbol", d.ConstantSymbol); err != nil {
+			return nil, err
+		}
+		if p.Symbols.SS, err = parseSymbol("specific symbol", d.SpecificSymbol); err != nil {
+			return nil, err
+		}
+		p.IBAN = strings.ReplaceAll(strings.TrimSpace(d.IBAN), " ", "")
+		p.BIC = strings.TrimSpace(d.BIC)
+
+		// domestic account number is preferred, Czech IBAN is the fallback
+		if accNum := strings.TrimSpace(d.ID); accNum != "" {
+			if p.Account, err = abo.ParseAccount(accNum); err != nil {
+				return nil, err
+			}
+			if p.Account.BankCode, err = parseSymbol("bank code", d.BankCode); err != nil {
+				return nil, err
+			}
+		} else if strings.HasPrefix(strings.ToUpper(p.IBAN), "CZ") {
+			if p.Account, err = abo.AccountFromIBAN(p.IBAN); err != nil {
+				return nil, err
+			}
+		}
+
+		inv.Payments = append(inv.Payments, p)
+	}
+
+	return inv, nil
+}
+
+// Read parses an ISDOC XML invoice
+func Read(rdr io.Reader) (*Invoice, error) {
+	var xp xmlInvoice
+	if err := xml.NewDecoder(rdr).Decode(&xp); err != nil {
+		return nil, newErr("unable to decode ISDOC: %w", err)
+	}
+
+	return xp.invoice()
+}
+
+// ReadISDOCX parses an .isdocx zip archive, reading the main document named
+// by its manifest or the first .isdoc file
+func ReadISDOCX(rdr io.ReaderAt, size int64) (*Invoice, error) {
+	zr, err := zip.NewReader(rdr, size)
+	if err != nil {
+		return nil, newErr("unable to open ISDOCX: %w", err)
+	}
+
+	var main string
+	for _, f := range zr.File {
+		if f.Name != "manifest.xml" {
+			continue
+		}
+		var manifest struct {
+			MainDocument struct {
+				Filename string `xml:"filename,attr"`
+			} `xml:"maindocument"`
+		}
+		mr, err := f.Open()
+		if err != nil {
+			return nil, newErr("unable to open ISDOCX manifest: %w", err)
+		}
+		err = xml.NewDecoder(mr).Decode(&manifest)
+		mr.Close()
+		if err != nil {
+			return nil, newErr("unable to decode ISDOCX manifest: %w", err)
+		}
+		main = manifest.MainDocument.Filename
+	}
+
+	for _, f := range zr.File {
+		if (main != "" && f.Name != main) || (main == "" && !strings.EqualFold(path.Ext(f.Name), ".isdoc")) {
+			continue
+		}
+		fr, err := f.Open()
+		if err != nil {
+			return nil, newErr("unable to open %s in ISDOCX: %w", f.Name, err)
+		}
+		defer fr.Close()
+		return Read(fr)
+	}
+
+	return nil, newErr("no ISDOC document in ISDOCX")
+}
+
+// ReadFile reads .isdoc or .isdocx file depending on its extension
+func ReadFile(name string) (*Invoice, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(path.Ext(name), ".isdocx") {
+		return ReadISDOCX(bytes.NewReader(data), int64(len(data)))
+	}
+	return Read(bytes.NewReader(data))
+}
+
+// Skipped is an invoice which was not added to the order
+type Skipped struct {
+	// Source is the invoice file name
+	Source string
+	Err    error
+}
+
+// Report describes the result of adding invoices to an order
+type Report struct {
+	Items   []*abo.Item
+	Skipped []Skipped
+}
+
+// AddToOrder appends bank transfer payments of the invoice to the order as items paid
+// from the payer account. Payments without due date are due on the issue date.
+// Invoices in currencies other than CZK are rejected.
+// ErrNoPaymentMeans is returned if the invoice has no bank transfer payment.
+func (inv *Invoice) AddToOrder(or *abo.Order, payer abo.Account) ([]*abo.Item, error) {
+	if inv.Currency != "" && !strings.EqualFold(inv.Currency, "CZK") {
+		return nil, newErr("invoice %s in currency %s can't be paid by ABO order", inv.ID, inv.Currency)
+	}
+
+	var transfers []*Payment
+	for i := range inv.Payments {
+		if inv.Payments[i].IsBankTransfer() {
+			transfers = append(transfers, &inv.Payments[i])
+		}
+	}
+	if len(transfers) == 0 {
+		return nil, ErrNoPaymentMeans
+	}
+
+	amounts := make([]float64, len(transfers))
+	for i, p := range transfers {
+		amounts[i] = p.Amount
+		if amounts[i] == 0 && len(transfers) == 1 {
+			amounts[i] = inv.Amount
+		}
+		if amounts[i] <= 0 {
+			return nil, newErr("invoice %s has no amount to pay", inv.ID)
+		}
+	}
+
+	var items []*abo.Item
+	for i, p := range transfers {
+		dueDate := p.DueDate
+		if dueDate.IsZero() {
+			dueDate = inv.IssueDate
+		}
+
+		it := or.AddPayment(payer, p.Account, amounts[i], p.Symbols, dueDate, inv.ID)
+		it.Recipient.Name = inv.Supplier
+		it.Recipient.IBAN = p.IBAN
+		it.Recipient.BIC = p.BIC
+		items = append(items, it)
+	}
+
+	return items, nil
+}
+
+// AddFiles reads invoice files and appends their payments to the order.
+// Files which can't be read or paid are reported as skipped.
+func AddFiles(or *abo.Order, payer abo.Account, names ...string) *Report {
+	rep := new(Report)
+
+	for _, name := range names {
+		inv, err := ReadFile(name)
+		if err == nil {
+			var items []*abo.Item
+			items, err = inv.AddToOrder(or, payer)
+			rep.Items = append(rep.Items, items...)
+		}
+		if err != nil {
+			rep.Skipped = append(rep.Skipped, Skipped{name, err})
+		}
+	}
+
+	return rep
+}
@@ -0,0 +1,119 @@
+package isdoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k3a/ago/abo"
+)
+
+func TestRead(t *testing.T) {
+	inv, err := ReadFile("test/invoice.isdoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inv.ID != "FV2024001" || inv.Supplier != "Dodavatel s.r.o." || inv.Amount != 12100 || inv.Currency != "CZK" {
+		t.Fatalf("unexpected invoice %+v", inv)
+	}
+	if len(inv.Payments) != 1 {
+		t.Fatalf("expected 1 payment, got %d", len(inv.Payments))
+	}
+
+	p := inv.Payments[0]
+	if p.Account != (abo.Account{Prefix: 19, Number: 2000145399, BankCode: 800}) {
+		t.Fatalf("unexpected account %v", p.Account)
+	}
+	if p.Symbols != (abo.Symbols{VS: 2024001, KS: 308}) {
+		t.Fatalf("unexpected symbols %+v", p.Symbols)
+	}
+	if !p.DueDate.Equal(time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC)) || !p.IsBankTransfer() {
+		t.Fatalf("unexpected payment %+v", p)
+	}
+}
+
+func TestAddToOrderForeignCurrency(t *testing.T) {
+	inv, err := ReadFile("test/foreign.isdoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Currency != "EUR" {
+		t.Fatalf("unexpected currency %s", inv.Currency)
+	}
+
+	or := new(abo.Order)
+	if _, err := inv.AddToOrder(or, abo.Account{Number: 2600113745}); err == nil || len(or.Groups) != 0 {
+		t.Fatal("foreign currency invoice not rejected")
+	}
+}
+
+func TestReadISDOCX(t *testing.T) {
+	data, err := os.ReadFile("test/invoice.isdoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"manifest.xml":  []byte(`<manifest><maindocument filename="faktura.isdoc"/></manifest>`),
+		"faktura.isdoc": data,
+		"faktura.pdf":   []byte("%PDF"),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "faktura.isdocx")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.ID != "FV2024001" || len(inv.Payments) != 1 {
+		t.Fatalf("unexpected invoice %+v", inv)
+	}
+}
+
+func TestAddFiles(t *testing.T) {
+	or := new(abo.Order)
+	payer := abo.Account{Number: 2600113745}
+
+	rep := AddFiles(or, payer, "test/invoice.isdoc", "test/cash.isdoc", "test/missing.isdoc")
+
+	if len(rep.Items) != 1 || len(or.Groups) != 1 || len(or.Groups[0].Items) != 1 {
+		t.Fatalf("expected single item, got %+v", rep)
+	}
+	it := rep.Items[0]
+	if it.Amount != 12100 || it.VS != 2024001 || it.KS != 308 || it.Recipient.AccountNum != 2000145399 ||
+		it.Recipient.Name != "Dodavatel s.r.o." || it.MessageForRecipient != "FV2024001" {
+		t.Fatalf("unexpected item %+v", it)
+	}
+	if or.Groups[0].Payer.AccountNum != 2600113745 || or.Groups[0].DueDate.Day() != 24 {
+		t.Fatalf("unexpected group %+v", or.Groups[0])
+	}
+
+	if len(rep.Skipped) != 2 {
+		t.Fatalf("expected 2 skipped invoices, got %+v", rep.Skipped)
+	}
+	if rep.Skipped[0].Source != "test/cash.isdoc" || !errors.Is(rep.Skipped[0].Err, ErrNoPaymentMeans) {
+		t.Fatalf("unexpected skipped invoice %+v", rep.Skipped[0])
+	}
+	if !errors.Is(rep.Skipped[1].Err, os.ErrNotExist) {
+		t.Fatalf("unexpected skipped invoice %+v", rep.Skipped[1])
+	}
+}
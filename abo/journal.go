@@ -0,0 +1,163 @@
+package abo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// AccountRule maps matching transactions to an account of the books.
+// Zero fields match any transaction.
+type AccountRule struct {
+	// Counterparty account, bank code is compared only if set
+	Counterparty Account
+	VS           int
+	KS           int
+	// Account is the target account, e.g. "Expenses:Utilities"
+	Account string
+}
+
+func (r *AccountRule) matches(txn *Transaction) bool {
+	if r.Counterparty.Number != 0 && (r.Counterparty.Number != txn.Recipient.AccountNum ||
+		r.Counterparty.Prefix != txn.Recipient.AccountNumPrefix) {
+		return false
+	}
+	if r.Counterparty.BankCode != 0 && r.Counterparty.BankCode != txn.Recipient.BankCode {
+		return false
+	}
+	if r.VS != 0 && r.VS != txn.VS {
+		return false
+	}
+	if r.KS != 0 && r.KS != txn.KS {
+		return false
+	}
+	return true
+}
+
+// JournalOptions controls export of statements to plain-text accounting journals
+type JournalOptions struct {
+	// Account of the bank account in the books, "Assets:Bank" if empty
+	Account string
+	// IncomeAccount is used for credits not matching any rule, "Income:Unknown" if empty
+	IncomeAccount string
+	// ExpenseAccount is used for debits not matching any rule, "Expenses:Unknown" if empty
+	ExpenseAccount string
+	// Rules are evaluated in order, the first matching rule determines the counter account
+	Rules []AccountRule
+	// OpeningAccount balances the ledger opening balance assignment, "Equity:Opening Balances" if empty
+	OpeningAccount string
+	// NoAssertions disables the opening balance entries and closing balance assertions
+	NoAssertions bool
+}
+
+func orDefault(str, def string) string {
+	if str == "" {
+		return def
+	}
+	return str
+}
+
+// counterAccount returns the account balancing the transaction
+func (opts *JournalOptions) counterAccount(txn *Transaction) string {
+	for i := range opts.Rules {
+		if opts.Rules[i].matches(txn) {
+			return opts.Rules[i].Account
+		}
+	}
+	if txn.IsDebit() {
+		return orDefault(opts.ExpenseAccount, "Expenses:Unknown")
+	}
+	return orDefault(opts.IncomeAccount, "Income:Unknown")
+}
+
+// payee returns single-line description of the transaction counterparty
+func (txn *Transaction) payee() string {
+	name := strings.Join(strings.Fields(txn.Recipient.Name), " ")
+	if name == "" {
+		return "Bank transaction"
+	}
+	return name
+}
+
+// WriteLedger writes the statement as journal entries compatible with ledger and hledger.
+// Opening balance is set by a balance assignment against the opening account,
+// so the journal is valid on its own, and closing balance is checked by a balance assertion.
+func (s *Statement) WriteLedger(wr io.Writer, opts JournalOptions) error {
+	var sb strings.Builder
+	bank := orDefault(opts.Account, "Assets:Bank")
+	ccy := s.currency().String()
+
+	if !opts.NoAssertions {
+		fmt.Fprintf(&sb, "%s * Opening balance\n    %s  = %s %s\n    %s\n\n", s.Info.StartDate.Format("2006-01-02"),
+			bank, formatAmount(s.Info.OpeningBalance), ccy, orDefault(opts.OpeningAccount, "Equity:Opening Balances"))
+	}
+
+	for _, txn := range s.Transactions {
+		tccy := ccy
+		if txn.Currency != currency.Unknown {
+			tccy = txn.Currency.String()
+		}
+		amount := txn.signedAmount()
+
+		fmt.Fprintf(&sb, "%s * %s\n", txn.DueDate.Format("2006-01-02"), txn.payee())
+		if memo := txn.memo(); memo != "" {
+			sb.WriteString("    ; " + memo + "\n")
+		}
+		if txn.ID != 0 {
+			sb.WriteString("    ; ID: " + strconv.Itoa(txn.ID) + "\n")
+		}
+		fmt.Fprintf(&sb, "    %s  %s %s\n", bank, formatAmount(amount), tccy)
+		fmt.Fprintf(&sb, "    %s  %s %s\n\n", opts.counterAccount(txn), formatAmount(-amount), tccy)
+	}
+
+	if !opts.NoAssertions {
+		fmt.Fprintf(&sb, "%s * Closing balance\n    %s  0 %s = %s %s\n\n", s.Info.EndDate.Format("2006-01-02"),
+			bank, ccy, formatAmount(s.Info.ClosingBalance), ccy)
+	}
+
+	_, err := io.WriteString(wr, sb.String())
+	return err
+}
+
+var beancountEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+// WriteBeancount writes the statement as beancount transactions. Opening balance is asserted
+// at the start date and closing balance the day after the end date, as beancount
+// checks balances at the beginning of a day. Open directives are not written.
+func (s *Statement) WriteBeancount(wr io.Writer, opts JournalOptions) error {
+	var sb strings.Builder
+	bank := orDefault(opts.Account, "Assets:Bank")
+	ccy := s.currency().String()
+
+	if !opts.NoAssertions {
+		fmt.Fprintf(&sb, "%s balance %s  %s %s\n\n", s.Info.StartDate.Format("2006-01-02"), bank,
+			formatAmount(s.Info.OpeningBalance), ccy)
+	}
+
+	for _, txn := range s.Transactions {
+		tccy := ccy
+		if txn.Currency != currency.Unknown {
+			tccy = txn.Currency.String()
+		}
+		amount := txn.signedAmount()
+
+		fmt.Fprintf(&sb, "%s * \"%s\" \"%s\"\n", txn.DueDate.Format("2006-01-02"),
+			beancountEscaper.Replace(txn.payee()), beancountEscaper.Replace(txn.memo()))
+		if txn.ID != 0 {
+			fmt.Fprintf(&sb, "  id: \"%d\"\n", txn.ID)
+		}
+		fmt.Fprintf(&sb, "  %s  %s %s\n", bank, formatAmount(amount), tccy)
+		fmt.Fprintf(&sb, "  %s  %s %s\n\n", opts.counterAccount(txn), formatAmount(-amount), tccy)
+	}
+
+	if !opts.NoAssertions {
+		fmt.Fprintf(&sb, "%s balance %s  %s %s\n", s.Info.EndDate.AddDate(0, 0, 1).Format("2006-01-02"), bank,
+			formatAmount(s.Info.ClosingBalance), ccy)
+	}
+
+	_, err := io.WriteString(wr, sb.String())
+	return err
+}
@@ -0,0 +1,112 @@
+package abo
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStatementWriteLedger(t *testing.T) {
+	s := readTestStatement(t)
+
+	buff := new(bytes.Buffer)
+	err := s.WriteLedger(buff, JournalOptions{
+		Account: "Assets:Bank:Fio",
+		Rules: []AccountRule{
+			{VS: 1, Account: "Expenses:Other"},
+			{Counterparty: Account{Number: 7770227, BankCode: 100}, Account: "Expenses:Utilities"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "2018-09-24 * Opening balance\n" +
+		"    Assets:Bank:Fio  = 313174.77 CZK\n" +
+		"    Equity:Opening Balances\n\n" +
+		"2018-09-24 * CEZ\n" +
+		"    ; VS:1446556401 KS:308 SS:7815392681 Acc:7770227/0100\n" +
+		"    ; ID: 16659818503\n" +
+		"    Assets:Bank:Fio  -1234.56 CZK\n" +
+		"    Expenses:Utilities  1234.56 CZK\n\n" +
+		"2018-09-24 * Closing balance\n" +
+		"    Assets:Bank:Fio  0 CZK = 310454.19 CZK\n\n"
+	if buff.String() != exp {
+		t.Fatalf("unexpected ledger\n%s", buff.String())
+	}
+}
+
+func TestStatementWriteLedgerBalances(t *testing.T) {
+	s := balanceStatement()
+	s.Info.ClosingBalance = 1049.8
+
+	buff := new(bytes.Buffer)
+	if err := s.WriteLedger(buff, JournalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// replay bank postings as ledger does, starting from an empty journal
+	var bal int64
+	var assigned, asserted bool
+	for _, line := range strings.Split(buff.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "Assets:Bank" {
+			continue
+		}
+		if fields[1] == "=" {
+			amount, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bal, assigned = ToHalere(amount), true
+			continue
+		}
+
+		amount, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bal += ToHalere(amount)
+		if len(fields) > 4 && fields[3] == "=" {
+			exp, err := strconv.ParseFloat(fields[4], 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bal != ToHalere(exp) {
+				t.Fatalf("balance assertion %v fails with balance %d\n%s", exp, bal, buff.String())
+			}
+			asserted = true
+		}
+	}
+	if !assigned || !asserted || !strings.Contains(buff.String(), "\n    Equity:Opening Balances\n") {
+		t.Fatalf("missing opening or closing balance\n%s", buff.String())
+	}
+}
+
+func TestStatementWriteBeancount(t *testing.T) {
+	s := readTestStatement(t)
+
+	buff := new(bytes.Buffer)
+	if err := s.WriteBeancount(buff, JournalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "2018-09-24 balance Assets:Bank  313174.77 CZK\n\n" +
+		"2018-09-24 * \"CEZ\" \"VS:1446556401 KS:308 SS:7815392681 Acc:7770227/0100\"\n" +
+		"  id: \"16659818503\"\n" +
+		"  Assets:Bank  -1234.56 CZK\n" +
+		"  Expenses:Unknown  1234.56 CZK\n\n" +
+		"2018-09-25 balance Assets:Bank  310454.19 CZK\n"
+	if buff.String() != exp {
+		t.Fatalf("unexpected beancount\n%s", buff.String())
+	}
+
+	buff.Reset()
+	if err := s.WriteBeancount(buff, JournalOptions{NoAssertions: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buff.String(), "balance") {
+		t.Fatal("unexpected balance assertion")
+	}
+}
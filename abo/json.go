@@ -0,0 +1,366 @@
+package abo
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// JSON encoding of statements and orders:
+//   - dates are "YYYY-MM-DD" strings, null if not set
+//   - amounts are decimal strings with two fractional digits, e.g. "1234.50"
+//   - currencies are ISO 4217 codes, e.g. "CZK"
+//   - accounts are "prefix-number/bank" strings, prefix and bank omitted if zero
+//   - symbols are numbers, omitted if zero
+
+const formatJSONDate = "2006-01-02"
+
+// jsonDate is time encoded as YYYY-MM-DD
+type jsonDate time.Time
+
+func (d jsonDate) MarshalJSON() ([]byte, error) {
+	if time.Time(d).IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(time.Time(d).Format(formatJSONDate))
+}
+
+func (d *jsonDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = jsonDate{}
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return newErr("invalid date %s", data)
+	}
+	if str == "" {
+		*d = jsonDate{}
+		return nil
+	}
+
+	tm, err := time.Parse(formatJSONDate, str)
+	if err != nil {
+		return newErr("invalid date %q", str)
+	}
+	*d = jsonDate(tm)
+	return nil
+}
+
+// jsonAmount is monetary amount encoded as decimal string
+type jsonAmount float64
+
+func (a jsonAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(formatAmount(float64(a)))
+}
+
+func (a *jsonAmount) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		// plain numbers are accepted too
+		str = string(data)
+	}
+
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return newErr("invalid amount %s", data)
+	}
+	*a = jsonAmount(val)
+	return nil
+}
+
+type jsonParty struct {
+	Name    string   `json:"name,omitempty"`
+	Account *Account `json:"account,omitempty"`
+	IBAN    string   `json:"iban,omitempty"`
+	BIC     string   `json:"bic,omitempty"`
+}
+
+// jsonAccount returns pointer to the account or nil if the number is not set
+func jsonAccount(prefix, number, bank int) *Account {
+	if number == 0 {
+		return nil
+	}
+	return &Account{prefix, number, bank}
+}
+
+func (p *jsonParty) account() Account {
+	if p.Account == nil {
+		return Account{}
+	}
+	return *p.Account
+}
+
+type jsonTransaction struct {
+	ID           int               `json:"id,omitempty"`
+	OwnerAccount *Account          `json:"ownerAccount,omitempty"`
+	Counterparty jsonParty         `json:"counterparty"`
+	Amount       jsonAmount        `json:"amount"`
+	Currency     currency.Currency `json:"currency"`
+	Type         int               `json:"type"`
+	Symbols
+	DueDate jsonDate `json:"dueDate"`
+}
+
+// MarshalJSON encodes the transaction, type is 1-debit, 2-credit, 4-storno-debit, 5-storno-credit
+func (txn Transaction) MarshalJSON() ([]byte, error) {
+	owner := accountFromNumber(txn.OwnerAccountNumber)
+
+	return json.Marshal(jsonTransaction{
+		ID:           txn.ID,
+		OwnerAccount: jsonAccount(owner.Prefix, owner.Number, 0),
+		Counterparty: jsonParty{
+			Name:    txn.Recipient.Name,
+			Account: jsonAccount(txn.Recipient.AccountNumPrefix, txn.Recipient.AccountNum, txn.Recipient.BankCode),
+		},
+		Amount:   jsonAmount(txn.Amount),
+		Currency: txn.Currency,
+		Type:     txn.Type,
+		Symbols:  Symbols{txn.VS, txn.KS, txn.SS},
+		DueDate:  jsonDate(txn.DueDate),
+	})
+}
+
+// UnmarshalJSON decodes the transaction
+func (txn *Transaction) UnmarshalJSON(data []byte) error {
+	var jt jsonTransaction
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return err
+	}
+
+	*txn = Transaction{
+		ID:       jt.ID,
+		Amount:   float64(jt.Amount),
+		Currency: jt.Currency,
+		Type:     jt.Type,
+		VS:       jt.VS,
+		KS:       jt.KS,
+		SS:       jt.SS,
+		DueDate:  time.Time(jt.DueDate),
+	}
+	if jt.OwnerAccount != nil {
+		txn.OwnerAccountNumber = jt.OwnerAccount.fullNumber()
+	}
+
+	acc := jt.Counterparty.account()
+	txn.Recipient.Name = jt.Counterparty.Name
+	txn.Recipient.AccountNumPrefix = acc.Prefix
+	txn.Recipient.AccountNum = acc.Number
+	txn.Recipient.BankCode = acc.BankCode
+
+	return nil
+}
+
+type jsonStatementInfo struct {
+	Account         *Account          `json:"account,omitempty"`
+	AccountName     string            `json:"accountName,omitempty"`
+	Currency        currency.Currency `json:"currency,omitempty"`
+	StartDate       jsonDate          `json:"startDate"`
+	EndDate         jsonDate          `json:"endDate"`
+	OpeningBalance  jsonAmount        `json:"openingBalance"`
+	ClosingBalance  jsonAmount        `json:"closingBalance"`
+	IncomeSum       jsonAmount        `json:"incomeSum"`
+	ExpenseSum      jsonAmount        `json:"expenseSum"`
+	StatementNumber int               `json:"statementNumber"`
+}
+
+type jsonStatement struct {
+	Info         jsonStatementInfo `json:"info"`
+	Transactions []*Transaction    `json:"transactions"`
+}
+
+// MarshalJSON encodes the statement
+func (s Statement) MarshalJSON() ([]byte, error) {
+	acc := accountFromNumber(s.Info.AccountNumber)
+
+	js := jsonStatement{
+		Info: jsonStatementInfo{
+			Account:         jsonAccount(acc.Prefix, acc.Number, s.Info.BankCode),
+			AccountName:     s.Info.AccountName,
+			Currency:        s.Info.Currency,
+			StartDate:       jsonDate(s.Info.StartDate),
+			EndDate:         jsonDate(s.Info.EndDate),
+			OpeningBalance:  jsonAmount(s.Info.OpeningBalance),
+			ClosingBalance:  jsonAmount(s.Info.ClosingBalance),
+			IncomeSum:       jsonAmount(s.Info.IncomeSum),
+			ExpenseSum:      jsonAmount(s.Info.ExpenseSum),
+			StatementNumber: s.Info.StatementNumber,
+		},
+		Transactions: s.Transactions,
+	}
+	if js.Transactions == nil {
+		js.Transactions = []*Transaction{}
+	}
+
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON decodes the statement
+func (s *Statement) UnmarshalJSON(data []byte) error {
+	var js jsonStatement
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+
+	*s = Statement{Transactions: js.Transactions}
+	if js.Info.Account != nil {
+		s.Info.AccountNumber = js.Info.Account.fullNumber()
+		s.Info.BankCode = js.Info.Account.BankCode
+	}
+	s.Info.AccountName = js.Info.AccountName
+	s.Info.Currency = js.Info.Currency
+	s.Info.StartDate = time.Time(js.Info.StartDate)
+	s.Info.EndDate = time.Time(js.Info.EndDate)
+	s.Info.OpeningBalance = float64(js.Info.OpeningBalance)
+	s.Info.ClosingBalance = float64(js.Info.ClosingBalance)
+	s.Info.IncomeSum = float64(js.Info.IncomeSum)
+	s.Info.ExpenseSum = float64(js.Info.ExpenseSum)
+	s.Info.StatementNumber = js.Info.StatementNumber
+	if s.Transactions == nil {
+		s.Transactions = []*Transaction{}
+	}
+
+	return nil
+}
+
+type jsonItem struct {
+	Recipient jsonParty         `json:"recipient"`
+	Amount    jsonAmount        `json:"amount"`
+	Currency  currency.Currency `json:"currency,omitempty"`
+	Symbols
+	Message    string `json:"message,omitempty"`
+	EndToEndID string `json:"endToEndId,omitempty"`
+}
+
+// MarshalJSON encodes the payment order item
+func (it Item) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonItem{
+		Recipient: jsonParty{
+			Name:    it.Recipient.Name,
+			Account: jsonAccount(it.Recipient.AccountNumPrefix, it.Recipient.AccountNum, it.Recipient.BankCode),
+			IBAN:    it.Recipient.IBAN,
+			BIC:     it.Recipient.BIC,
+		},
+		Amount:     jsonAmount(it.Amount),
+		Currency:   it.Currency,
+		Symbols:    Symbols{it.VS, it.KS, it.SS},
+		Message:    it.MessageForRecipient,
+		EndToEndID: it.EndToEndID,
+	})
+}
+
+// UnmarshalJSON decodes the payment order item
+func (it *Item) UnmarshalJSON(data []byte) error {
+	var ji jsonItem
+	if err := json.Unmarshal(data, &ji); err != nil {
+		return err
+	}
+
+	acc := ji.Recipient.account()
+	*it = Item{
+		Amount:              float64(ji.Amount),
+		Currency:            ji.Currency,
+		VS:                  ji.VS,
+		KS:                  ji.KS,
+		SS:                  ji.SS,
+		MessageForRecipient: ji.Message,
+		EndToEndID:          ji.EndToEndID,
+	}
+	it.Recipient.AccountNumPrefix = acc.Prefix
+	it.Recipient.AccountNum = acc.Number
+	it.Recipient.BankCode = acc.BankCode
+	it.Recipient.Name = ji.Recipient.Name
+	it.Recipient.IBAN = ji.Recipient.IBAN
+	it.Recipient.BIC = ji.Recipient.BIC
+
+	return nil
+}
+
+type jsonGroup struct {
+	Payer   jsonParty `json:"payer"`
+	DueDate jsonDate  `json:"dueDate"`
+	Items   []*Item   `json:"items"`
+}
+
+// MarshalJSON encodes the payment group, payer account has no bank code
+func (gr Group) MarshalJSON() ([]byte, error) {
+	jg := jsonGroup{
+		Payer: jsonParty{
+			Account: jsonAccount(gr.Payer.AccountNumPrefix, gr.Payer.AccountNum, 0),
+			IBAN:    gr.Payer.IBAN,
+			BIC:     gr.Payer.BIC,
+		},
+		DueDate: jsonDate(gr.DueDate),
+		Items:   gr.Items,
+	}
+	if jg.Items == nil {
+		jg.Items = []*Item{}
+	}
+
+	return json.Marshal(jg)
+}
+
+// UnmarshalJSON decodes the payment group
+func (gr *Group) UnmarshalJSON(data []byte) error {
+	var jg jsonGroup
+	if err := json.Unmarshal(data, &jg); err != nil {
+		return err
+	}
+
+	acc := jg.Payer.account()
+	*gr = Group{DueDate: time.Time(jg.DueDate), Items: jg.Items}
+	gr.Payer.AccountNumPrefix = acc.Prefix
+	gr.Payer.AccountNum = acc.Number
+	gr.Payer.IBAN = jg.Payer.IBAN
+	gr.Payer.BIC = jg.Payer.BIC
+
+	return nil
+}
+
+type jsonOrder struct {
+	CreationDate jsonDate  `json:"creationDate"`
+	Client       jsonParty `json:"client"`
+	Number       int       `json:"number"`
+	Groups       []*Group  `json:"groups"`
+}
+
+// MarshalJSON encodes the order
+func (or Order) MarshalJSON() ([]byte, error) {
+	jo := jsonOrder{
+		CreationDate: jsonDate(or.CreationDate),
+		Client: jsonParty{
+			Name:    or.Client.Name,
+			Account: jsonAccount(0, or.Client.AccountNumber, or.Client.BankCode),
+		},
+		Number: or.Number,
+		Groups: or.Groups,
+	}
+	if jo.Groups == nil {
+		jo.Groups = []*Group{}
+	}
+
+	return json.Marshal(jo)
+}
+
+// UnmarshalJSON decodes the order
+func (or *Order) UnmarshalJSON(data []byte) error {
+	var jo jsonOrder
+	if err := json.Unmarshal(data, &jo); err != nil {
+		return err
+	}
+
+	acc := jo.Client.account()
+	if acc.Prefix != 0 {
+		return newErr("client account %s can't have a prefix", acc)
+	}
+
+	*or = Order{CreationDate: time.Time(jo.CreationDate), Number: jo.Number, Groups: jo.Groups}
+	or.Client.Name = jo.Client.Name
+	or.Client.AccountNumber = acc.Number
+	or.Client.BankCode = acc.BankCode
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package abo
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatementJSON(t *testing.T) {
+	rdr, err := os.Open("./test/fio.gpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromReader(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt.Info.BankCode = 2010
+
+	data, err := json.Marshal(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{
+		`"account":"2600113745/2010"`,
+		`"startDate":"2018-09-24"`,
+		`"openingBalance":"313174.77"`,
+		`"counterparty":{"name":"CEZ","account":"7770227/0100"}`,
+		`"amount":"1234.56","currency":"CZK","type":1,"vs":1446556401,"ks":308,"ss":7815392681,"dueDate":"2018-09-24"`,
+	} {
+		if !strings.Contains(string(data), exp) {
+			t.Fatalf("%s not found in %s", exp, data)
+		}
+	}
+
+	back := new(Statement)
+	if err := json.Unmarshal(data, back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, stmt) {
+		t.Fatalf("statement mismatch\n%v\n%v", stmt, back)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"1","currency":"XYZ"}`), new(Transaction)); err == nil {
+		t.Fatal("expected error for unknown currency")
+	}
+}
+
+func TestOrderJSON(t *testing.T) {
+	o := new(Order)
+	o.CreationDate = time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+	o.Client.Name = "Firma"
+	o.Client.AccountNumber = 2101135843
+	o.Client.BankCode = 2010
+
+	it := o.AddPayment(Account{19, 2000145399, 0}, Account{0, 1900133399, 2010}, 1.5, Symbols{VS: 123}, o.CreationDate, "Faktura")
+	it.EndToEndID = "E2E"
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"creationDate":"2024-09-18","client":{"name":"Firma","account":"2101135843/2010"},"number":0,` +
+		`"groups":[{"payer":{"account":"19-2000145399"},"dueDate":"2024-09-18","items":[{"recipient":{"account":"1900133399/2010"},` +
+		`"amount":"1.50","vs":123,"message":"Faktura","endToEndId":"E2E"}]}]}`
+	if string(data) != exp {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+
+	back := new(Order)
+	if err := json.Unmarshal(data, back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, o) {
+		t.Fatal("order mismatch")
+	}
+}
@@ -0,0 +1,108 @@
+package abo
+
+import (
+	"sort"
+	"time"
+)
+
+// Conflict is a transaction ID found in several statements with different content
+type Conflict struct {
+	ID int
+	// Kept is the transaction of the merged statement, Other is the differing duplicate
+	Kept  *Transaction
+	Other *Transaction
+}
+
+// sameContent reports whether transactions have equal content
+func (txn *Transaction) sameContent(other *Transaction) bool {
+	a, b := *txn, *other
+	a.DueDate, b.DueDate = time.Time{}, time.Time{}
+	return a == b && txn.DueDate.Equal(other.DueDate)
+}
+
+// contentKey returns transaction content comparable by ==
+func (txn *Transaction) contentKey() Transaction {
+	key := *txn
+	key.DueDate = key.DueDate.UTC()
+	return key
+}
+
+// MergeStatements combines overlapping statements of one account into a single
+// statement with transactions sorted by date. Transactions are deduplicated by ID,
+// transactions without ID by content. Same ID with different content is reported as
+// a conflict keeping the transaction of the earlier statement. Balances are taken from
+// the first and the last statement, income and expense sums are recomputed.
+func MergeStatements(stmts ...*Statement) (*Statement, []Conflict, error) {
+	if len(stmts) == 0 {
+		return nil, nil, newErr("no statements to merge")
+	}
+	for _, s := range stmts[1:] {
+		if s.Info.AccountNumber != stmts[0].Info.AccountNumber {
+			return nil, nil, newErr("statements of different accounts %d and %d", stmts[0].Info.AccountNumber, s.Info.AccountNumber)
+		}
+	}
+
+	sorted := append([]*Statement{}, stmts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Info.StartDate.Equal(sorted[j].Info.StartDate) {
+			return sorted[i].Info.StartDate.Before(sorted[j].Info.StartDate)
+		}
+		return sorted[i].Info.StatementNumber < sorted[j].Info.StatementNumber
+	})
+
+	merged := &Statement{Transactions: []*Transaction{}}
+	first := sorted[0]
+	merged.Info = first.Info
+	merged.Info.IncomeSum, merged.Info.ExpenseSum = 0, 0
+	for _, s := range sorted[1:] {
+		if merged.Info.AccountName == "" {
+			merged.Info.AccountName = s.Info.AccountName
+		}
+		if merged.Info.BankCode == 0 {
+			merged.Info.BankCode = s.Info.BankCode
+		}
+		if !s.Info.EndDate.Before(merged.Info.EndDate) {
+			merged.Info.EndDate = s.Info.EndDate
+			merged.Info.ClosingBalance = s.Info.ClosingBalance
+			merged.Info.StatementNumber = s.Info.StatementNumber
+		}
+	}
+
+	var txns []*Transaction
+	byID := make(map[int]*Transaction)
+	// number of transactions without ID with the same content already merged
+	noID := make(map[Transaction]int)
+	var conflicts []Conflict
+
+	for _, s := range sorted {
+		seen := make(map[Transaction]int)
+		for _, txn := range s.Transactions {
+			if txn.ID == 0 {
+				key := txn.contentKey()
+				seen[key]++
+				if seen[key] > noID[key] {
+					noID[key]++
+					txns = append(txns, txn)
+				}
+				continue
+			}
+
+			kept, ok := byID[txn.ID]
+			if !ok {
+				byID[txn.ID] = txn
+				txns = append(txns, txn)
+			} else if !kept.sameContent(txn) {
+				conflicts = append(conflicts, Conflict{txn.ID, kept, txn})
+			}
+		}
+	}
+
+	sort.SliceStable(txns, func(i, j int) bool {
+		return txns[i].DueDate.Before(txns[j].DueDate)
+	})
+	for _, txn := range txns {
+		merged.addTransaction(txn)
+	}
+
+	return merged, conflicts, nil
+}
@@ -0,0 +1,71 @@
+package abo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeStatements(t *testing.T) {
+	day := time.Date(2024, 9, 1, 0, 0, 0, 0, time.Local)
+	day5, day6 := day.AddDate(0, 0, 5), day.AddDate(0, 0, 6)
+
+	s1 := new(Statement)
+	s1.Info.AccountNumber = 2600113745
+	s1.Info.StartDate = day
+	s1.Info.EndDate = day.AddDate(0, 0, 9)
+	s1.Info.OpeningBalance = 1000
+	s1.Info.ClosingBalance = 1100
+	s1.Info.StatementNumber = 1
+	s1.Transactions = []*Transaction{
+		{ID: 1, Amount: 100, Type: TypeCredit, DueDate: day},
+		{Amount: 50, Type: TypeCredit, DueDate: day5},
+		{Amount: 50, Type: TypeDebit, DueDate: day5},
+		{ID: 2, Amount: 30, Type: TypeCredit, DueDate: day6},
+	}
+
+	s2 := new(Statement)
+	s2.Info.AccountNumber = s1.Info.AccountNumber
+	s2.Info.StartDate = day5
+	s2.Info.EndDate = day.AddDate(0, 0, 14)
+	s2.Info.ClosingBalance = 1300
+	s2.Info.StatementNumber = 2
+	s2.Transactions = []*Transaction{
+		{Amount: 50, Type: TypeCredit, DueDate: day5},
+		{Amount: 50, Type: TypeDebit, DueDate: day5},
+		{Amount: 50, Type: TypeDebit, DueDate: day5},
+		{ID: 2, Amount: 31, Type: TypeCredit, DueDate: day6},
+		{ID: 3, Amount: 200, Type: TypeCredit, DueDate: day.AddDate(0, 0, 12)},
+	}
+
+	merged, conflicts, err := MergeStatements(s2, s1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(merged.Transactions) != 6 {
+		t.Fatalf("expected 6 transactions, got %d", len(merged.Transactions))
+	}
+	for i := 1; i < len(merged.Transactions); i++ {
+		if merged.Transactions[i].DueDate.Before(merged.Transactions[i-1].DueDate) {
+			t.Fatal("transactions not sorted")
+		}
+	}
+	if merged.Info.OpeningBalance != 1000 || merged.Info.ClosingBalance != 1300 || merged.Info.StatementNumber != 2 {
+		t.Fatalf("unexpected balances %+v", merged.Info)
+	}
+	if !merged.Info.StartDate.Equal(day) || !merged.Info.EndDate.Equal(day.AddDate(0, 0, 14)) {
+		t.Fatalf("unexpected dates %+v", merged.Info)
+	}
+	if merged.Info.IncomeSum != 380 || merged.Info.ExpenseSum != 100 {
+		t.Fatalf("unexpected sums %v %v", merged.Info.IncomeSum, merged.Info.ExpenseSum)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].ID != 2 || conflicts[0].Kept.Amount != 30 || conflicts[0].Other.Amount != 31 {
+		t.Fatalf("unexpected conflicts %+v", conflicts)
+	}
+
+	s2.Info.AccountNumber = 1
+	if _, _, err := MergeStatements(s1, s2); err == nil {
+		t.Fatal("statements of different accounts merged")
+	}
+}
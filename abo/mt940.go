@@ -0,0 +1,414 @@
+package abo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+const formatYYMMDD = "060102"
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+var (
+	mt940TagRe     = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):(.*)$`)
+	mt940BalanceRe = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+	mt940LineRe    = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^\n]*?)(?://([^\n]*))?(?:\n(?s:(.*)))?$`)
+	mt940SubRe     = regexp.MustCompile(`\?(\d{2})`)
+)
+
+// splitMT940 splits the input into messages of tagged fields. SWIFT
+// block headers are skipped and continuation lines joined by newline.
+func splitMT940(rdr io.Reader) ([][]mt940Field, error) {
+	var msgs [][]mt940Field
+	var cur []mt940Field
+
+	scn := bufio.NewScanner(rdr)
+	for scn.Scan() {
+		line := strings.TrimRight(scn.Text(), "\r ")
+
+		// skip SWIFT envelope {1:...}{2:...}{4:
+		if strings.HasPrefix(line, "{") {
+			if idx := strings.Index(line, "{4:"); idx >= 0 {
+				line = line[idx+3:]
+			} else {
+				continue
+			}
+		}
+
+		switch {
+		case line == "":
+			continue
+		case line == "-" || line == "-}" || strings.HasPrefix(line, "-}"):
+			if len(cur) > 0 {
+				msgs = append(msgs, cur)
+			}
+			cur = nil
+		case mt940TagRe.MatchString(line):
+			m := mt940TagRe.FindStringSubmatch(line)
+			cur = append(cur, mt940Field{m[1], m[2]})
+		case len(cur) > 0:
+			cur[len(cur)-1].value += "\n" + line
+		}
+	}
+	if err := scn.Err(); err != nil {
+		return nil, newErr("unable to read MT940: %v", err)
+	}
+
+	if len(cur) > 0 {
+		msgs = append(msgs, cur)
+	}
+
+	return msgs, nil
+}
+
+func parseMT940Amount(str string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(str, ",", ".", 1), 64)
+}
+
+// parseMT940Balance parses :60F:/:62F: balance value
+func parseMT940Balance(str string) (float64, time.Time, currency.Currency, error) {
+	m := mt940BalanceRe.FindStringSubmatch(strings.TrimSpace(str))
+	if m == nil {
+		return 0, time.Time{}, currency.Unknown, newErr("invalid MT940 balance %q", str)
+	}
+
+	date, err := time.Parse(formatYYMMDD, m[2])
+	if err != nil {
+		return 0, time.Time{}, currency.Unknown, newErr("invalid MT940 balance date %q", m[2])
+	}
+
+	amount, err := parseMT940Amount(m[4])
+	if err != nil {
+		return 0, time.Time{}, currency.Unknown, newErr("invalid MT940 balance amount %q", m[4])
+	}
+	if m[1] == "D" {
+		amount = -amount
+	}
+
+	return amount, date, currency.FromString(m[3]), nil
+}
+
+// parseMT940Account parses :25: account identification as IBAN or Czech account number
+func parseMT940Account(str string) Account {
+	str = strings.TrimSpace(str)
+
+	if acc, err := AccountFromIBAN(str); err == nil {
+		return acc
+	}
+	if acc, err := ParseAccount(str); err == nil {
+		return acc
+	}
+
+	// bank/account format
+	if parts := strings.SplitN(str, "/", 2); len(parts) == 2 {
+		if acc, err := ParseAccount(parts[1] + "/" + parts[0]); err == nil {
+			return acc
+		}
+	}
+
+	return Account{}
+}
+
+// parseMT940Info parses :86: information, either free text or ?nn structured subfields
+func parseMT940Info(txn *Transaction, info string, refs ...string) {
+	texts := refs
+
+	if loc := mt940SubRe.FindStringIndex(info); loc != nil {
+		subs := map[string]string{}
+
+		// continuation lines are concatenated to subfields
+		info = strings.ReplaceAll(info, "\n", "")
+		idx := mt940SubRe.FindAllStringSubmatchIndex(info, -1)
+		for i, m := range idx {
+			end := len(info)
+			if i+1 < len(idx) {
+				end = idx[i+1][0]
+			}
+			subs[info[m[2]:m[3]]] += info[m[1]:end]
+		}
+
+		var remittance []string
+		for _, key := range []string{"20", "21", "22", "23", "24", "25", "26", "27", "28", "29", "60", "61", "62", "63"} {
+			if subs[key] != "" {
+				remittance = append(remittance, subs[key])
+			}
+		}
+		texts = append(texts, strings.Join(remittance, ""), subs["00"])
+
+		acc := Account{}
+		if a, err := AccountFromIBAN(subs["31"]); err == nil {
+			acc = a
+		} else if a, err := ParseAccount(subs["31"]); err == nil {
+			acc = a
+		}
+		if acc.BankCode == 0 {
+			acc.BankCode, _ = strconv.Atoi(strings.TrimSpace(subs["30"])) //nolint:gosec,zero if unknown
+		}
+		txn.Recipient.AccountNumPrefix = acc.Prefix
+		txn.Recipient.AccountNum = acc.Number
+		txn.Recipient.BankCode = acc.BankCode
+		txn.Recipient.Name = strings.TrimSpace(subs["32"] + subs["33"])
+	} else {
+		texts = append(texts, info)
+	}
+
+	sym := ParseSymbols(texts...)
+	txn.VS, txn.KS, txn.SS = sym.VS, sym.KS, sym.SS
+}
+
+func parseMT940Line(str string, ccy currency.Currency, owner int) (*Transaction, error) {
+	m := mt940LineRe.FindStringSubmatch(str)
+	if m == nil {
+		return nil, newErr("invalid MT940 statement line %q", str)
+	}
+
+	txn := &Transaction{OwnerAccountNumber: owner, Currency: ccy}
+
+	var err error
+	if txn.DueDate, err = time.Parse(formatYYMMDD, m[1]); err != nil {
+		return nil, newErr("invalid MT940 value date %q", m[1])
+	}
+	if txn.Amount, err = parseMT940Amount(m[5]); err != nil {
+		return nil, newErr("invalid MT940 amount %q", m[5])
+	}
+
+	switch m[3] {
+	case "D":
+		txn.Type = TypeDebit
+	case "C":
+		txn.Type = TypeCredit
+	case "RD":
+		txn.Type = TypeStornoDebit
+	case "RC":
+		txn.Type = TypeStornoCredit
+	}
+
+	txn.ID = refID(m[8], m[7])
+
+	// references may carry symbols too
+	parseMT940Info(txn, "", m[7], m[8], m[9])
+
+	return txn, nil
+}
+
+func mt940Statement(fields []mt940Field) (*Statement, error) {
+	s := new(Statement)
+	s.Transactions = []*Transaction{}
+
+	var last *Transaction
+	var lastRefs []string
+
+	for _, f := range fields {
+		var err error
+
+		switch f.tag {
+		case "25":
+			acc := parseMT940Account(f.value)
+			s.Info.AccountNumber = acc.fullNumber()
+			s.Info.BankCode = acc.BankCode
+		case "28", "28C":
+			s.Info.StatementNumber = refID(strings.SplitN(f.value, "/", 2)[0])
+		case "60F", "60M":
+			s.Info.OpeningBalance, s.Info.StartDate, s.Info.Currency, err = parseMT940Balance(f.value)
+		case "62F", "62M":
+			s.Info.ClosingBalance, s.Info.EndDate, _, err = parseMT940Balance(f.value)
+		case "61":
+			last, err = parseMT940Line(f.value, s.Info.Currency, s.Info.AccountNumber)
+			if err == nil {
+				m := mt940LineRe.FindStringSubmatch(f.value)
+				lastRefs = []string{m[7], m[8], m[9]}
+				s.Transactions = append(s.Transactions, last)
+			}
+		case "86":
+			if last != nil {
+				parseMT940Info(last, f.value, lastRefs...)
+				last = nil
+			} else if s.Info.AccountName == "" {
+				s.Info.AccountName = strings.TrimSpace(strings.ReplaceAll(f.value, "\n", " "))
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, txn := range s.Transactions {
+		if txn.IsDebit() {
+			s.Info.ExpenseSum += txn.Amount
+		} else {
+			s.Info.IncomeSum += txn.Amount
+		}
+	}
+	s.Info.IncomeSum = roundAmount(s.Info.IncomeSum)
+	s.Info.ExpenseSum = roundAmount(s.Info.ExpenseSum)
+
+	return s, nil
+}
+
+// FromMT940All parses all statements of SWIFT MT940 input
+func FromMT940All(rdr io.Reader) ([]*Statement, error) {
+	// close input if possible
+	defer func() {
+		if rdrc, ok := rdr.(io.ReadCloser); ok {
+			rdrc.Close() //nolint:gosec
+		}
+	}()
+
+	msgs, err := splitMT940(rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts []*Statement
+	for _, fields := range msgs {
+		s, err := mt940Statement(fields)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, s)
+	}
+
+	return stmts, nil
+}
+
+// FromMT940 parses SWIFT MT940 input returning its first statement
+func FromMT940(rdr io.Reader) (*Statement, error) {
+	stmts, err := FromMT940All(rdr)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) == 0 {
+		return nil, newErr("no statement in MT940 input")
+	}
+
+	return stmts[0], nil
+}
+
+func formatMT940Amount(amount float64) string {
+	return strings.Replace(formatAmount(amount), ".", ",", 1)
+}
+
+func formatMT940Balance(amount float64, date time.Time, ccy currency.Currency) string {
+	ind := "C"
+	if amount < 0 {
+		ind, amount = "D", -amount
+	}
+	return ind + date.Format(formatYYMMDD) + ccy.String() + formatMT940Amount(amount)
+}
+
+// wrapMT940 splits a field value into at most 6 lines of 65 characters including the tag,
+// dropping what doesn't fit
+func wrapMT940(tag, str string) string {
+	var lines []string
+	width := 65 - len(tag) - 2
+	for len(str) > width && len(lines) < 5 {
+		lines = append(lines, str[:width])
+		str = str[width:]
+		width = 65
+	}
+	if len(str) > width {
+		str = str[:width]
+	}
+	return strings.Join(append(lines, str), "\r\n")
+}
+
+// writeMT940Subfields writes the value as consecutive ?nn subfields of at most 27 characters
+// starting with subfield first, dropping what doesn't fit into count subfields
+func writeMT940Subfields(sb *strings.Builder, first, count int, str string) {
+	for i := 0; i < count && len(str) > 0; i++ {
+		n := len(str)
+		if n > 27 {
+			n = 27
+		}
+		fmt.Fprintf(sb, "?%02d%s", first+i, str[:n])
+		str = str[n:]
+	}
+}
+
+// mt940Info formats :86: structured information with Czech symbols
+func mt940Info(txn *Transaction) string {
+	var sb strings.Builder
+
+	if txn.IsDebit() {
+		sb.WriteString("177?00PLATBA")
+	} else {
+		sb.WriteString("166?00PRIJEM")
+	}
+	if ref := symbolsRef(txn.VS, txn.KS, txn.SS); ref != "" {
+		writeMT940Subfields(&sb, 20, 10, ref)
+	}
+	if txn.Recipient.BankCode != 0 {
+		fmt.Fprintf(&sb, "?30%04d", txn.Recipient.BankCode)
+	}
+	if txn.Recipient.AccountNum != 0 {
+		acc := Account{Prefix: txn.Recipient.AccountNumPrefix, Number: txn.Recipient.AccountNum}
+		sb.WriteString("?31" + acc.String())
+	}
+
+	// SWIFT character set is ASCII only and ? separates subfields
+	name, _ := sanitizeText("name", txn.Recipient.Name, TextTransliterate, ".") //nolint:gosec,never fails
+	name = strings.ReplaceAll(name, "?", ".")
+	writeMT940Subfields(&sb, 32, 2, name)
+
+	return sb.String()
+}
+
+// WriteMT940 writes the statement as SWIFT MT940 message. Account is identified by IBAN
+// if Info.BankCode is known. Symbols and counterparty are written as :86: structured subfields.
+func (s *Statement) WriteMT940(wr io.Writer) error {
+	ccy := s.currency()
+	acc := accountFromNumber(s.Info.AccountNumber)
+
+	var lines []string
+	add := func(tag, value string) {
+		lines = append(lines, ":"+tag+":"+value)
+	}
+
+	add("20", fmt.Sprintf("STMT%d", s.Info.StatementNumber))
+	if s.Info.BankCode != 0 {
+		acc.BankCode = s.Info.BankCode
+		add("25", acc.IBAN())
+	} else {
+		add("25", acc.String())
+	}
+	add("28C", fmt.Sprintf("%05d/1", s.Info.StatementNumber))
+	add("60F", formatMT940Balance(s.Info.OpeningBalance, s.Info.StartDate, ccy))
+
+	for _, txn := range s.Transactions {
+		ind := "C"
+		switch txn.Type {
+		case TypeDebit:
+			ind = "D"
+		case TypeStornoDebit:
+			ind = "RD"
+		case TypeStornoCredit:
+			ind = "RC"
+		}
+
+		line := txn.DueDate.Format(formatYYMMDD) + txn.DueDate.Format("0102") + ind +
+			formatMT940Amount(txn.Amount) + "NTRFNONREF"
+		if txn.ID != 0 {
+			line += "//" + strconv.Itoa(txn.ID)
+		}
+
+		add("61", line)
+		add("86", wrapMT940("86", mt940Info(txn)))
+	}
+
+	add("62F", formatMT940Balance(s.Info.ClosingBalance, s.Info.EndDate, ccy))
+	lines = append(lines, "-")
+
+	_, err := io.WriteString(wr, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
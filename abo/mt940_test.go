@@ -0,0 +1,116 @@
+package abo
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+func TestFromMT940(t *testing.T) {
+	rdr, err := os.Open("./test/statement.mt940")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromMT940(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stmt.Info.AccountNumber != 2600113745 || stmt.Info.BankCode != 2010 || stmt.Info.StatementNumber != 27 {
+		t.Fatal("bad statement info")
+	}
+	if stmt.Info.OpeningBalance != 313174.77 || stmt.Info.ClosingBalance != 310454.19 || stmt.Info.Currency != currency.CZK {
+		t.Fatal("bad balances")
+	}
+	if stmt.Info.IncomeSum != 1234.56 || stmt.Info.ExpenseSum != 3955.14 {
+		t.Fatal("bad sums")
+	}
+	if len(stmt.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(stmt.Transactions))
+	}
+
+	tr := stmt.Transactions[0]
+	if tr.Type != TypeCredit || tr.Amount != 1234.56 || tr.ID != 27022700166 || tr.DueDate.Format("2006-01-02") != "2024-09-18" {
+		t.Fatal("bad credit transaction")
+	}
+	if tr.VS != 1446556401 || tr.KS != 308 || tr.SS != 7815392681 {
+		t.Fatal("bad symbols from free text")
+	}
+
+	tr = stmt.Transactions[1]
+	if tr.Type != TypeDebit || tr.VS != 2024015 || tr.Recipient.Name != "Dodavatel s.r.o." {
+		t.Fatal("bad debit transaction")
+	}
+	if tr.Recipient.AccountNumPrefix != 19 || tr.Recipient.AccountNum != 2000145399 || tr.Recipient.BankCode != 800 {
+		t.Fatal("bad counterparty account")
+	}
+}
+
+func TestStatementWriteMT940(t *testing.T) {
+	rdr, err := os.Open("./test/fio.gpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromReader(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt.Transactions[0].Recipient.Name = "Elektrárna Počerady a.s. Černé Uhlí"
+
+	buff := new(bytes.Buffer)
+	if err := stmt.WriteMT940(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(buff.String(), "\r\n") {
+		if len(line) > 65 {
+			t.Fatalf("line too long: %s", line)
+		}
+	}
+
+	back, err := FromMT940(buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if back.Info.AccountNumber != stmt.Info.AccountNumber || back.Info.OpeningBalance != stmt.Info.OpeningBalance ||
+		back.Info.ClosingBalance != stmt.Info.ClosingBalance || !back.Info.EndDate.Equal(stmt.Info.EndDate) {
+		t.Fatal("bad statement info")
+	}
+
+	exp := *stmt.Transactions[0]
+	exp.Recipient.Name = "Elektrarna Pocerady a.s. Cerne Uhli"
+	if *back.Transactions[0] != exp {
+		t.Fatalf("transaction mismatch\n%v\n%v", &exp, back.Transactions[0])
+	}
+}
+
+func TestStatementWriteMT940Limits(t *testing.T) {
+	txn := &Transaction{Type: TypeCredit, Amount: 10, VS: 1234567890, KS: 308, SS: 9876543210}
+	txn.Recipient.Name = strings.Repeat("Dlouhé jméno ", 6)
+
+	info := mt940Info(txn)
+	for _, sub := range strings.Split(info, "?")[1:] {
+		if len(sub) > 2+27 {
+			t.Fatalf("subfield too long: %s", sub)
+		}
+	}
+	if !strings.Contains(info, "?33") || strings.Contains(info, "?34") {
+		t.Fatalf("bad name subfields: %s", info)
+	}
+
+	back := new(Transaction)
+	parseMT940Info(back, info)
+	if back.VS != txn.VS || back.KS != txn.KS || back.SS != txn.SS || len(back.Recipient.Name) != 54 {
+		t.Fatalf("bad parsed info %v", back)
+	}
+
+	if lines := strings.Split(wrapMT940("86", strings.Repeat("x", 1000)), "\r\n"); len(lines) != 6 || len(lines[5]) != 65 {
+		t.Fatalf("bad wrapping %v", lines)
+	}
+}
@@ -0,0 +1,200 @@
+package abo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OFXVersion selects OFX format version
+type OFXVersion int
+
+// Supported OFX versions
+const (
+	// OFX102 is SGML based OFX 1.0.2
+	OFX102 OFXVersion = 102
+	// OFX220 is XML based OFX 2.2
+	OFX220 OFXVersion = 220
+)
+
+// memo describes the transaction symbols and counterparty account
+func (txn *Transaction) memo() string {
+	var parts []string
+	if txn.VS != 0 {
+		parts = append(parts, "VS:"+strconv.Itoa(txn.VS))
+	}
+	if txn.KS != 0 {
+		parts = append(parts, "KS:"+strconv.Itoa(txn.KS))
+	}
+	if txn.SS != 0 {
+		parts = append(parts, "SS:"+strconv.Itoa(txn.SS))
+	}
+	if txn.Recipient.AccountNum != 0 {
+		acc := Account{txn.Recipient.AccountNumPrefix, txn.Recipient.AccountNum, txn.Recipient.BankCode}
+		parts = append(parts, "Acc:"+acc.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// signedAmount returns the amount negative for debits
+func (txn *Transaction) signedAmount() float64 {
+	if txn.IsDebit() {
+		return -txn.Amount
+	}
+	return txn.Amount
+}
+
+// fitID returns unique transaction ID for import tools, generated if ID is not known
+func (s *Statement) fitID(idx int, txn *Transaction) string {
+	if txn.ID != 0 {
+		return strconv.Itoa(txn.ID)
+	}
+	return fmt.Sprintf("%d-%s-%d", s.Info.AccountNumber, txn.DueDate.Format("20060102"), idx+1)
+}
+
+type ofxWriter struct {
+	sb  strings.Builder
+	xml bool
+}
+
+var ofxEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (ow *ofxWriter) open(tag string) {
+	ow.sb.WriteString("<" + tag + ">\n")
+}
+
+func (ow *ofxWriter) close(tag string) {
+	ow.sb.WriteString("</" + tag + ">\n")
+}
+
+// leaf writes an element, closed only in XML based versions
+func (ow *ofxWriter) leaf(tag, value string) {
+	ow.sb.WriteString("<" + tag + ">" + ofxEscaper.Replace(value))
+	if ow.xml {
+		ow.sb.WriteString("</" + tag + ">")
+	}
+	ow.sb.WriteString("\n")
+}
+
+func (ow *ofxWriter) status() {
+	ow.open("STATUS")
+	ow.leaf("CODE", "0")
+	ow.leaf("SEVERITY", "INFO")
+	ow.close("STATUS")
+}
+
+func truncRunes(str string, n int) string {
+	if r := []rune(str); len(r) > n {
+		return string(r[:n])
+	}
+	return str
+}
+
+// WriteOFX writes the statement as OFX bank statement response. Transaction ID is used
+// as FITID, counterparty name as NAME and symbols as MEMO.
+func (s *Statement) WriteOFX(wr io.Writer, version OFXVersion) error {
+	ow := &ofxWriter{xml: version >= 200}
+
+	switch version {
+	case OFX102:
+		ow.sb.WriteString("OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\nSECURITY:NONE\nENCODING:UNICODE\n" +
+			"CHARSET:NONE\nCOMPRESSION:NONE\nOLDFILEUID:NONE\nNEWFILEUID:NONE\n\n")
+	case OFX220:
+		ow.sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+			`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	default:
+		return newErr("unsupported OFX version %d", version)
+	}
+
+	const dateFmt = "20060102"
+	acc := accountFromNumber(s.Info.AccountNumber)
+	acc.BankCode = 0
+
+	ow.open("OFX")
+	ow.open("SIGNONMSGSRSV1")
+	ow.open("SONRS")
+	ow.status()
+	ow.leaf("DTSERVER", time.Now().Format("20060102150405"))
+	ow.leaf("LANGUAGE", "CES")
+	ow.close("SONRS")
+	ow.close("SIGNONMSGSRSV1")
+
+	ow.open("BANKMSGSRSV1")
+	ow.open("STMTTRNRS")
+	ow.leaf("TRNUID", strconv.Itoa(s.Info.StatementNumber))
+	ow.status()
+	ow.open("STMTRS")
+	ow.leaf("CURDEF", s.currency().String())
+	ow.open("BANKACCTFROM")
+	ow.leaf("BANKID", fmt.Sprintf("%04d", s.Info.BankCode))
+	ow.leaf("ACCTID", acc.String())
+	ow.leaf("ACCTTYPE", "CHECKING")
+	ow.close("BANKACCTFROM")
+
+	ow.open("BANKTRANLIST")
+	ow.leaf("DTSTART", s.Info.StartDate.Format(dateFmt))
+	ow.leaf("DTEND", s.Info.EndDate.Format(dateFmt))
+	for idx, txn := range s.Transactions {
+		ow.open("STMTTRN")
+		if txn.IsDebit() {
+			ow.leaf("TRNTYPE", "DEBIT")
+		} else {
+			ow.leaf("TRNTYPE", "CREDIT")
+		}
+		ow.leaf("DTPOSTED", txn.DueDate.Format(dateFmt))
+		ow.leaf("TRNAMT", formatAmount(txn.signedAmount()))
+		ow.leaf("FITID", s.fitID(idx, txn))
+		if txn.Recipient.Name != "" {
+			ow.leaf("NAME", truncRunes(txn.Recipient.Name, 32))
+		}
+		if txn.Recipient.AccountNum != 0 {
+			ow.open("BANKACCTTO")
+			ow.leaf("BANKID", fmt.Sprintf("%04d", txn.Recipient.BankCode))
+			ow.leaf("ACCTID", Account{Prefix: txn.Recipient.AccountNumPrefix, Number: txn.Recipient.AccountNum}.String())
+			ow.leaf("ACCTTYPE", "CHECKING")
+			ow.close("BANKACCTTO")
+		}
+		if memo := txn.memo(); memo != "" {
+			ow.leaf("MEMO", memo)
+		}
+		ow.close("STMTTRN")
+	}
+	ow.close("BANKTRANLIST")
+
+	ow.open("LEDGERBAL")
+	ow.leaf("BALAMT", formatAmount(s.Info.ClosingBalance))
+	ow.leaf("DTASOF", s.Info.EndDate.Format(dateFmt))
+	ow.close("LEDGERBAL")
+	ow.close("STMTRS")
+	ow.close("STMTTRNRS")
+	ow.close("BANKMSGSRSV1")
+	ow.close("OFX")
+
+	_, err := io.WriteString(wr, ow.sb.String())
+	return err
+}
+
+// WriteQIF writes the statement as QIF bank account transactions with MM/DD/YYYY dates.
+// Transaction ID is used as check number, counterparty name as payee and symbols as memo.
+func (s *Statement) WriteQIF(wr io.Writer) error {
+	var sb strings.Builder
+
+	sb.WriteString("!Type:Bank\n")
+	for idx, txn := range s.Transactions {
+		sb.WriteString("D" + txn.DueDate.Format("01/02/2006") + "\n")
+		sb.WriteString("T" + formatAmount(txn.signedAmount()) + "\n")
+		sb.WriteString("N" + s.fitID(idx, txn) + "\n")
+		if txn.Recipient.Name != "" {
+			sb.WriteString("P" + strings.ReplaceAll(txn.Recipient.Name, "\n", " ") + "\n")
+		}
+		if memo := txn.memo(); memo != "" {
+			sb.WriteString("M" + memo + "\n")
+		}
+		sb.WriteString("^\n")
+	}
+
+	_, err := io.WriteString(wr, sb.String())
+	return err
+}
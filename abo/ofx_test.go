@@ -0,0 +1,72 @@
+package abo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readTestStatement(t *testing.T) *Statement {
+	rdr, err := os.Open("./test/fio.gpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := FromReader(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stmt
+}
+
+func TestStatementWriteOFX(t *testing.T) {
+	stmt := readTestStatement(t)
+	stmt.Transactions[0].Recipient.Name = "Tom & Jerry <CZ>"
+
+	buff := new(bytes.Buffer)
+	if err := stmt.WriteOFX(buff, OFX102); err != nil {
+		t.Fatal(err)
+	}
+	sgml := buff.String()
+	if !strings.HasPrefix(sgml, "OFXHEADER:100\n") || !strings.Contains(sgml, "\nENCODING:UNICODE\nCHARSET:NONE\n") || !strings.Contains(sgml, "<TRNAMT>-1234.56\n<FITID>16659818503\n<NAME>Tom &amp; Jerry &lt;CZ&gt;\n") {
+		t.Fatalf("unexpected OFX 1.x\n%s", sgml)
+	}
+
+	buff.Reset()
+	if err := stmt.WriteOFX(buff, OFX220); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Txns []struct {
+			Type   string `xml:"TRNTYPE"`
+			Amount string `xml:"TRNAMT"`
+			FITID  string
+			Name   string `xml:"NAME"`
+			Memo   string `xml:"MEMO"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+	}
+	if err := xml.Unmarshal(buff.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Txns) != 1 || doc.Txns[0].Type != "DEBIT" || doc.Txns[0].Name != "Tom & Jerry <CZ>" ||
+		doc.Txns[0].Memo != "VS:1446556401 KS:308 SS:7815392681 Acc:7770227/0100" || doc.Balance != "310454.19" {
+		t.Fatalf("unexpected OFX 2.x\n%s", buff.String())
+	}
+}
+
+func TestStatementWriteQIF(t *testing.T) {
+	buff := new(bytes.Buffer)
+	if err := readTestStatement(t).WriteQIF(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "!Type:Bank\nD09/24/2018\nT-1234.56\nN16659818503\nPCEZ\nMVS:1446556401 KS:308 SS:7815392681 Acc:7770227/0100\n^\n"
+	if buff.String() != exp {
+		t.Fatalf("unexpected QIF\n%s", buff.String())
+	}
+}
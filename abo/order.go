@@ -2,8 +2,10 @@ package abo
 
 import (
 	"io"
-	"os"
+	"sort"
 	"time"
+
+	"github.com/k3a/ago/abo/currency"
 )
 
 // Item is a payment order item
@@ -12,12 +14,21 @@ type Item struct {
 		AccountNumPrefix int
 		AccountNum       int
 		BankCode         int
+		// Name, IBAN and BIC are used by SEPA and QR payment export only
+		Name string
+		IBAN string
+		BIC  string
 	}
 	Amount              float64
 	VS                  int
 	KS                  int
 	SS                  int
 	MessageForRecipient string
+	// EndToEndID identifies SEPA payment, derived from symbols if empty
+	EndToEndID string
+	// Currency of the amount, unknown means the currency of the format,
+	// i.e. CZK for KPC and EUR for SEPA
+	Currency currency.Currency
 }
 
 // Group groups payment order items to be made from a single fund source
@@ -25,6 +36,9 @@ type Group struct {
 	Payer struct {
 		AccountNumPrefix int
 		AccountNum       int
+		// IBAN and BIC are used by SEPA export, IBAN is derived from the account if empty
+		IBAN string
+		BIC  string
 	}
 	DueDate time.Time
 
@@ -39,90 +53,93 @@ type Order struct {
 		AccountNumber int
 		BankCode      int
 	}
+	// Number is the sequence number of the accounting file
+	Number int
 	Groups []*Group
 }
 
 // Write writes an item to a writer
 func (it *Item) Write(inWr io.Writer) error { //nolint:gocyclo,doesn't make sense
+	if it.Currency != currency.Unknown && it.Currency != currency.CZK {
+		return newErr("item in %s can't be written to KPC order", it.Currency)
+	}
+
 	wr := newWriter(inWr)
 
 	// recipient account number (format: 000000-0000000000)
-	if err := wr.WriteInt(it.Recipient.AccountNumPrefix, 6); err != nil {
+	if err := wr.WriteInt("recipient account prefix", it.Recipient.AccountNumPrefix, 6); err != nil {
 		return err
 	}
-	if err := wr.WriteStr("-", 1); err != nil {
+	if err := wr.WriteSep("-"); err != nil {
 		return err
 	}
-	if err := wr.WriteInt(it.Recipient.AccountNum, 10); err != nil {
+	if err := wr.WriteInt("recipient account number", it.Recipient.AccountNum, 10); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// amount
-	if err := wr.WriteMonetaryAmount(it.Amount, 15); err != nil {
+	if err := wr.WriteMonetaryAmount("amount", it.Amount, 15); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// VS
-	if err := wr.WriteInt(it.VS, 10); err != nil {
+	if err := wr.WriteInt("VS", it.VS, 10); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// bank
-	if err := wr.WriteInt(it.Recipient.BankCode, 4); err != nil {
+	if err := wr.WriteInt("recipient bank code", it.Recipient.BankCode, 4); err != nil {
 		return err
 	}
 
 	// KS
-	if err := wr.WriteInt(it.KS, 4); err != nil {
+	if err := wr.WriteInt("KS", it.KS, 4); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// SS (optional)
 	if it.SS != 0 {
-		if err := wr.WriteInt(it.SS, 10); err != nil {
+		if err := wr.WriteInt("SS", it.SS, 10); err != nil {
 			return err
 		}
 	} else {
 		// field separator if SS not specified
-		if err := wr.WriteStr(" ", 1); err != nil {
+		if err := wr.WriteSep(" "); err != nil {
 			return err
 		}
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
-	// msg for recipient (optional)
+	// msg for recipient (optional, up to 4 lines of 35 chars)
 	if len(it.MessageForRecipient) > 0 {
-		maxMsgLen := 4 * 35
-		trimmedMsg := it.MessageForRecipient
-		if len(it.MessageForRecipient) > maxMsgLen {
-			trimmedMsg = trimmedMsg[:maxMsgLen]
+		if err := wr.WriteSep("AV:"); err != nil {
+			return err
 		}
-
-		if err := wr.WriteStrWindows1250("AV:"+trimmedMsg, 3+ /*maxMsgLen*/ len(trimmedMsg)); err != nil {
+		if err := wr.WriteVarStrWindows1250("message for recipient", it.MessageForRecipient, 4*35); err != nil {
 			return err
 		}
 	}
@@ -136,23 +153,23 @@ func (gr *Group) Write(inWr io.Writer) error { //nolint:gocyclo,doesn't make sen
 	wr := newWriter(inWr)
 
 	// start of group (msg type + sep)
-	if err := wr.WriteStr("2 ", 1+1); err != nil {
+	if err := wr.WriteSep("2 "); err != nil {
 		return err
 	}
 
 	// payer account (000000-0000000000)
-	if err := wr.WriteInt(gr.Payer.AccountNumPrefix, 6); err != nil {
+	if err := wr.WriteInt("payer account prefix", gr.Payer.AccountNumPrefix, 6); err != nil {
 		return err
 	}
-	if err := wr.WriteStr("-", 1); err != nil {
+	if err := wr.WriteSep("-"); err != nil {
 		return err
 	}
-	if err := wr.WriteInt(gr.Payer.AccountNum, 10); err != nil {
+	if err := wr.WriteInt("payer account number", gr.Payer.AccountNum, 10); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
@@ -161,17 +178,17 @@ func (gr *Group) Write(inWr io.Writer) error { //nolint:gocyclo,doesn't make sen
 	for _, it := range gr.Items {
 		totalAmount += it.Amount
 	}
-	if err := wr.WriteMonetaryAmount(totalAmount, 14); err != nil {
+	if err := wr.WriteMonetaryAmount("group total amount", totalAmount, 14); err != nil {
 		return err
 	}
 
 	// field separator
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// due date
-	if err := wr.WriteTime(gr.DueDate); err != nil {
+	if err := wr.WriteTime("due date", gr.DueDate); err != nil {
 		return err
 	}
 
@@ -188,7 +205,7 @@ func (gr *Group) Write(inWr io.Writer) error { //nolint:gocyclo,doesn't make sen
 	}
 
 	// end of group
-	if err := wr.WriteStr("3 +", 3); err != nil {
+	if err := wr.WriteSep("3 +"); err != nil {
 		return err
 	}
 
@@ -224,27 +241,27 @@ func (or *Order) writeAccounting(inWr io.Writer) error { //nolint:gocyclo,doesnt
 	wr := newWriter(inWr)
 
 	// start of accounting (msg type + sep)
-	if err := wr.WriteStr("1 ", 1+1); err != nil {
+	if err := wr.WriteSep("1 "); err != nil {
 		return err
 	}
 
 	// type of data + sep
-	if err := wr.WriteInt(1501, 4); err != nil {
+	if err := wr.WriteInt("data type", 1501, 4); err != nil {
 		return err
 	}
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
 	// accounting file number + sep
-	if err := wr.WriteInt(0, 6); err != nil {
+	if err := wr.WriteInt("accounting file number", or.Number, 6); err != nil {
 		return err
 	}
-	if err := wr.WriteStr(" ", 1); err != nil {
+	if err := wr.WriteSep(" "); err != nil {
 		return err
 	}
 
-	if err := wr.WriteInt(or.Client.BankCode, 4); err != nil {
+	if err := wr.WriteInt("client bank code", or.Client.BankCode, 4); err != nil {
 		return err
 	}
 
@@ -261,7 +278,7 @@ func (or *Order) writeAccounting(inWr io.Writer) error { //nolint:gocyclo,doesnt
 	}
 
 	// end of accounting
-	if err := wr.WriteStr("5 +", 3); err != nil {
+	if err := wr.WriteSep("5 +"); err != nil {
 		return err
 	}
 
@@ -274,62 +291,85 @@ func (or *Order) Write(inWr io.Writer) error {
 	wr := newWriter(inWr)
 
 	// Message type
-	if err := wr.WriteStr("UHL1", 4); err != nil {
-		return newErr("unable to write msg header: %v", err)
+	if err := wr.WriteSep("UHL1"); err != nil {
+		return newErr("unable to write msg header: %w", err)
 	}
 
 	// creation date
-	if err := wr.WriteTime(or.CreationDate); err != nil {
-		return newErr("unable to write creation date: %v", err)
+	if err := wr.WriteTime("creation date", or.CreationDate); err != nil {
+		return newErr("unable to write creation date: %w", err)
 	}
 
 	// name of client
-	if err := wr.WriteStrWindows1250(or.Client.Name, 20); err != nil {
-		return newErr("unable to write client name: %v", err)
+	if err := wr.WriteStrWindows1250("client name", or.Client.Name, 20); err != nil {
+		return newErr("unable to write client name: %w", err)
 	}
 
 	// client acc num
-	if err := wr.WriteInt(or.Client.AccountNumber, 10); err != nil {
-		return newErr("unable to write client account number: %v", err)
+	if err := wr.WriteInt("client account number", or.Client.AccountNumber, 10); err != nil {
+		return newErr("unable to write client account number: %w", err)
 	}
 
 	// Interval of accounting files - start
-	if err := wr.WriteInt(0, 3); err != nil {
-		return newErr("unable to write acc interval start: %v", err)
+	if err := wr.WriteInt("interval start", 0, 3); err != nil {
+		return newErr("unable to write acc interval start: %w", err)
 	}
 
 	// Interval of accounting files - end
-	if err := wr.WriteInt(999, 3); err != nil {
-		return newErr("unable to write acc interval end: %v", err)
+	if err := wr.WriteInt("interval end", 999, 3); err != nil {
+		return newErr("unable to write acc interval end: %w", err)
 	}
 
 	// Code fixed part
-	if err := wr.WriteInt(0, 6); err != nil {
-		return newErr("unable to write fixed part: %v", err)
+	if err := wr.WriteInt("fixed code", 0, 6); err != nil {
+		return newErr("unable to write fixed part: %w", err)
 	}
 
 	// Code secret part
-	if err := wr.WriteInt(0, 6); err != nil {
-		return newErr("unable to write secret part: %v", err)
+	if err := wr.WriteInt("secret code", 0, 6); err != nil {
+		return newErr("unable to write secret part: %w", err)
 	}
 
 	// new line
 	if err := wr.WriteLineEnd(); err != nil {
-		return newErr("unable to write line end: %v", err)
+		return newErr("unable to write line end: %w", err)
 	}
 
 	return or.writeAccounting(wr)
 }
 
-// WriteToFile writes the order to a .kpc file
-func (or *Order) WriteToFile(path string) error {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return newErr("unable to open target file %s: %s", path, err)
+// WriteOptions controls writing of an order
+type WriteOptions struct {
+	// Overflow determines handling of text values longer than their fields
+	Overflow OverflowPolicy
+	// Text determines handling of characters not accepted in text fields
+	Text TextPolicy
+	// Replacement is used by TextReplace policy, "?" if empty
+	Replacement string
+}
+
+// WriteReport describes changes made to the order data to fit the format
+type WriteReport struct {
+	Truncations []Truncation
+	TextChanges []TextChange
+}
+
+// WriteWithOptions writes the order to a writer using the options
+// and reports the values which had to be changed
+func (or *Order) WriteWithOptions(inWr io.Writer, opts WriteOptions) (*WriteReport, error) {
+	wr := &writer{Writer: inWr, policy: opts.Overflow, textPolicy: opts.Text, replacement: opts.Replacement}
+
+	if err := or.Write(wr); err != nil {
+		return nil, err
 	}
-	defer f.Close()
 
-	return or.Write(f)
+	return &WriteReport{Truncations: wr.truncations, TextChanges: wr.textChanges}, nil
+}
+
+// WriteToFile atomically writes the order to a .kpc file,
+// replacing an existing file
+func (or *Order) WriteToFile(path string) error {
+	return or.WriteToFileOpts(path, FileOptions{})
 }
 
 // AddGroup adds a payment group. It is a group of payment orders sent
@@ -345,3 +385,44 @@ func (or *Order) AddGroup(payerAccountPrefix, payerAccountNum int, dueDate time.
 
 	return gr
 }
+
+// AddPayment adds a payment order to the group of the payer account and due date,
+// creating the group if it doesn't exist yet. Groups are kept sorted by payer
+// account and due date. Bank code of the payer is ignored as all groups
+// of the order are sent from the client's bank.
+func (or *Order) AddPayment(payer, recipient Account, amount float64, sym Symbols, dueDate time.Time, msgForRecp string) *Item {
+	gr := or.findGroup(payer, dueDate)
+	if gr == nil {
+		gr = or.AddGroup(payer.Prefix, payer.Number, dueDate)
+		or.sortGroups()
+	}
+
+	return gr.AddItem(recipient.Prefix, recipient.Number, recipient.BankCode, amount, sym.VS, sym.KS, sym.SS, msgForRecp)
+}
+
+func (or *Order) findGroup(payer Account, dueDate time.Time) *Group {
+	y, m, d := dueDate.Date()
+
+	for _, gr := range or.Groups {
+		gy, gm, gd := gr.DueDate.Date()
+		if gr.Payer.AccountNumPrefix == payer.Prefix && gr.Payer.AccountNum == payer.Number &&
+			gy == y && gm == m && gd == d {
+			return gr
+		}
+	}
+
+	return nil
+}
+
+func (or *Order) sortGroups() {
+	sort.SliceStable(or.Groups, func(i, j int) bool {
+		a, b := or.Groups[i], or.Groups[j]
+		if a.Payer.AccountNumPrefix != b.Payer.AccountNumPrefix {
+			return a.Payer.AccountNumPrefix < b.Payer.AccountNumPrefix
+		}
+		if a.Payer.AccountNum != b.Payer.AccountNum {
+			return a.Payer.AccountNum < b.Payer.AccountNum
+		}
+		return a.DueDate.Before(b.DueDate)
+	})
+}
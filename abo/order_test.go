@@ -2,8 +2,12 @@ package abo
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/k3a/ago/abo/currency"
 )
 
 func TestOrder(t *testing.T) {
@@ -24,3 +28,112 @@ func TestOrder(t *testing.T) {
 
 	//t.Fatal(buff.String())
 }
+
+func TestOrderCurrency(t *testing.T) {
+	o := new(Order)
+	o.CreationDate = time.Now()
+
+	gr := o.AddGroup(0, 2101135843, time.Now().Add(24*time.Hour))
+	gr.AddItemSimple(0, 1900133399, 2010, 1.23, 88888888, "")
+
+	gr.Items[0].Currency = currency.CZK
+	if err := o.Write(new(bytes.Buffer)); err != nil {
+		t.Fatal(err)
+	}
+
+	gr.Items[0].Currency = currency.EUR
+	if err := o.Write(new(bytes.Buffer)); err == nil {
+		t.Fatal("EUR item written to KPC order")
+	}
+}
+
+func TestOrderAddPayment(t *testing.T) {
+	o := new(Order)
+
+	payer := Account{Number: 2101135843, BankCode: 2010}
+	payer2 := Account{Number: 2101135800, BankCode: 2010}
+	recp := Account{Number: 1900133399, BankCode: 2010}
+	day := time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+
+	o.AddPayment(payer, recp, 10, Symbols{VS: 1}, day.Add(24*time.Hour), "")
+	o.AddPayment(payer, recp, 20, Symbols{VS: 2}, day, "")
+	o.AddPayment(payer2, recp, 30, Symbols{VS: 3}, day.Add(48*time.Hour), "")
+	o.AddPayment(payer, recp, 40, Symbols{VS: 4, KS: 308}, day.Add(10*time.Hour), "")
+
+	if len(o.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(o.Groups))
+	}
+	if o.Groups[0].Payer.AccountNum != payer2.Number {
+		t.Fatal("groups not sorted by payer")
+	}
+	if !o.Groups[1].DueDate.Equal(day) || len(o.Groups[1].Items) != 2 || o.Groups[1].Items[1].KS != 308 {
+		t.Fatal("payments with the same due date not grouped")
+	}
+}
+
+func TestOrderOverflow(t *testing.T) {
+	o := new(Order)
+	o.CreationDate = time.Now()
+	o.Client.Name = "Příliš dlouhé jméno klienta"
+
+	gr := o.AddGroup(0, 2101135843, time.Now())
+	gr.AddItemSimple(0, 1900133399, 2010, 1.23, 88888888, "")
+
+	var oerr *OverflowError
+	if err := o.Write(new(bytes.Buffer)); !errors.As(err, &oerr) || oerr.Field != "client name" || oerr.Width != 20 {
+		t.Fatalf("expected client name overflow, got %v", err)
+	} else if oerr.Value != o.Client.Name || strings.Contains(err.Error(), "abo: abo:") {
+		t.Fatalf("bad overflow error %v", err)
+	}
+
+	buff := new(bytes.Buffer)
+	rep, err := o.WriteWithOptions(buff, WriteOptions{Overflow: OverflowTruncate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Truncations) != 1 || rep.Truncations[0].Field != "client name" {
+		t.Fatalf("unexpected truncations %v", rep.Truncations)
+	}
+	if rep.Truncations[0].Value != o.Client.Name || rep.Truncations[0].Written != "Příliš dlouhé jméno " {
+		t.Fatalf("truncation not reported in UTF-8: %q", rep.Truncations[0].Written)
+	}
+	if !strings.HasPrefix(strings.SplitN(buff.String(), "\n", 2)[0], "UHL1") || len(strings.SplitN(buff.String(), "\n", 2)[0]) != 4+6+20+10+3+3+6+6 {
+		t.Fatal("bad header length")
+	}
+
+	// numbers are never truncated
+	gr.AddItemSimple(0, 1900133399, 2010, -0.5, 1, "")
+	if _, err := o.WriteWithOptions(new(bytes.Buffer), WriteOptions{Overflow: OverflowTruncate}); !errors.As(err, &oerr) || oerr.Field != "amount" {
+		t.Fatalf("expected negative amount error, got %v", err)
+	}
+	gr.Items[1].Amount = 1
+	gr.Items[1].VS = 12345678901
+	if _, err := o.WriteWithOptions(new(bytes.Buffer), WriteOptions{Overflow: OverflowTruncate}); !errors.As(err, &oerr) || oerr.Field != "VS" {
+		t.Fatalf("expected VS overflow, got %v", err)
+	}
+}
+
+func TestOrderTextPolicy(t *testing.T) {
+	o := new(Order)
+	o.CreationDate = time.Now()
+	o.Client.Name = "Čapek"
+
+	gr := o.AddGroup(0, 2101135843, time.Now())
+	gr.AddItemSimple(0, 1900133399, 2010, 1.23, 88888888, "Faktura 10€ 👍")
+
+	if err := o.Write(new(bytes.Buffer)); err == nil {
+		t.Fatal("expected error for emoji in strict mode")
+	}
+
+	buff := new(bytes.Buffer)
+	rep, err := o.WriteWithOptions(buff, WriteOptions{Text: TextTransliterate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.TextChanges) != 2 || rep.TextChanges[1].Field != "message for recipient" || rep.TextChanges[1].Written != "Faktura 10EUR ?" {
+		t.Fatalf("unexpected text changes %v", rep.TextChanges)
+	}
+	if !strings.Contains(buff.String(), "Capek") || !strings.Contains(buff.String(), "AV:Faktura 10EUR ?\n") {
+		t.Fatal("transliterated text not written")
+	}
+}
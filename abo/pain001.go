@@ -0,0 +1,262 @@
+package abo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// Pain001Version is a version of ISO 20022 customer credit transfer initiation message
+type Pain001Version string
+
+// Supported pain.001 versions
+const (
+	Pain001V03 Pain001Version = "pain.001.001.03"
+	Pain001V09 Pain001Version = "pain.001.001.09"
+)
+
+const painNamespace = "urn:iso:std:iso:20022:tech:xsd:"
+
+type painDocument struct {
+	XMLName xml.Name       `xml:"Document"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Initn   painInitiation `xml:"CstmrCdtTrfInitn"`
+}
+
+type painInitiation struct {
+	GrpHdr painGroupHeader
+	PmtInf []*painPaymentInfo
+}
+
+type painGroupHeader struct {
+	MsgID    string `xml:"MsgId"`
+	CreDtTm  string
+	NbOfTxs  int
+	CtrlSum  string
+	InitgPty painParty
+}
+
+type painParty struct {
+	Nm string `xml:",omitempty"`
+}
+
+type painExecutionDate struct {
+	Date string `xml:",chardata"`
+	Dt   string `xml:",omitempty"`
+}
+
+type painPaymentInfo struct {
+	PmtInfID    string `xml:"PmtInfId"`
+	PmtMtd      string
+	NbOfTxs     int
+	CtrlSum     string
+	SvcLvlCd    string `xml:"PmtTpInf>SvcLvl>Cd"`
+	ReqdExctnDt painExecutionDate
+	Dbtr        painParty
+	DbtrAcct    painAccount
+	DbtrAgt     painAgent
+	ChrgBr      string
+	CdtTrfTxInf []*painTransaction
+}
+
+type painAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type painAgent struct {
+	FinInstnID painInstitution `xml:"FinInstnId"`
+}
+
+type painInstitution struct {
+	BIC   string `xml:",omitempty"`
+	BICFI string `xml:",omitempty"`
+	Othr  *painOther
+}
+
+type painOther struct {
+	ID string `xml:"Id"`
+}
+
+type painAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type painTransaction struct {
+	EndToEndID string     `xml:"PmtId>EndToEndId"`
+	InstdAmt   painAmount `xml:"Amt>InstdAmt"`
+	CdtrAgt    *painAgent
+	Cdtr       painParty
+	CdtrAcct   painAccount
+	RmtInf     *painRemittance
+}
+
+type painRemittance struct {
+	Ustrd string
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(float64(ToHalere(amount))/100, 'f', 2, 64)
+}
+
+func newPainAgent(bic string, version Pain001Version) painAgent {
+	switch {
+	case bic == "":
+		return painAgent{painInstitution{Othr: &painOther{"NOTPROVIDED"}}}
+	case version == Pain001V03:
+		return painAgent{painInstitution{BIC: bic}}
+	default:
+		return painAgent{painInstitution{BICFI: bic}}
+	}
+}
+
+func checkMaxText(field, value string, maxLen int) error {
+	if utf8.RuneCountInString(value) > maxLen {
+		return newErr("invalid SEPA payment: %w", &OverflowError{field, value, maxLen})
+	}
+	return nil
+}
+
+// sepaEndToEndID returns the end-to-end ID of the item,
+// using the Czech /VS/.../SS/.../KS/... convention if not set explicitly
+func (it *Item) sepaEndToEndID() string {
+	if it.EndToEndID != "" {
+		return it.EndToEndID
+	}
+	if it.VS == 0 && it.SS == 0 && it.KS == 0 {
+		return "NOTPROVIDED"
+	}
+	return fmt.Sprintf("/VS/%d/SS/%d/KS/%d", it.VS, it.SS, it.KS)
+}
+
+func (it *Item) painTransaction(version Pain001Version) (*painTransaction, error) {
+	if it.Currency != currency.Unknown && it.Currency != currency.EUR {
+		return nil, newErr("item in %s can't be written to SEPA credit transfer", it.Currency)
+	}
+
+	iban := it.Recipient.IBAN
+	if iban == "" && it.Recipient.AccountNum != 0 {
+		iban = Account{it.Recipient.AccountNumPrefix, it.Recipient.AccountNum, it.Recipient.BankCode}.IBAN()
+	}
+	if err := ValidateIBAN(iban); err != nil {
+		return nil, newErr("invalid recipient IBAN: %w", err)
+	}
+
+	if it.Recipient.Name == "" {
+		return nil, newErr("missing recipient name of SEPA payment to %s", iban)
+	}
+
+	tx := &painTransaction{
+		EndToEndID: it.sepaEndToEndID(),
+		InstdAmt:   painAmount{"EUR", formatAmount(it.Amount)},
+		Cdtr:       painParty{it.Recipient.Name},
+		CdtrAcct:   painAccount{normalizeIBAN(iban)},
+	}
+	if it.MessageForRecipient != "" {
+		tx.RmtInf = &painRemittance{it.MessageForRecipient}
+	}
+	if it.Recipient.BIC != "" {
+		agent := newPainAgent(it.Recipient.BIC, version)
+		tx.CdtrAgt = &agent
+	}
+
+	if err := checkMaxText("end-to-end ID", tx.EndToEndID, 35); err != nil {
+		return nil, err
+	}
+	if err := checkMaxText("recipient name", tx.Cdtr.Nm, 140); err != nil {
+		return nil, err
+	}
+	if err := checkMaxText("message for recipient", it.MessageForRecipient, 140); err != nil {
+		return nil, err
+	}
+	if ToHalere(it.Amount) <= 0 {
+		return nil, newErr("invalid amount %s of SEPA payment to %s", formatAmount(it.Amount), iban)
+	}
+
+	return tx, nil
+}
+
+// WritePain001 writes the order as ISO 20022 SEPA credit transfer initiation in EUR.
+// Each group becomes a payment information block. Payer IBAN is derived from the group payer
+// account and client bank code if not set.
+func (or *Order) WritePain001(wr io.Writer, version Pain001Version) error {
+	if version != Pain001V03 && version != Pain001V09 {
+		return newErr("unsupported pain.001 version %s", version)
+	}
+
+	msgID := fmt.Sprintf("%s-%03d", or.CreationDate.Format("20060102150405"), or.Number)
+
+	doc := painDocument{Xmlns: painNamespace + string(version)}
+	doc.Initn.GrpHdr = painGroupHeader{
+		MsgID:    msgID,
+		CreDtTm:  or.CreationDate.Format("2006-01-02T15:04:05"),
+		InitgPty: painParty{or.Client.Name},
+	}
+
+	var total int64
+	for grIdx, gr := range or.Groups {
+		iban := gr.Payer.IBAN
+		if iban == "" {
+			iban = Account{gr.Payer.AccountNumPrefix, gr.Payer.AccountNum, or.Client.BankCode}.IBAN()
+		}
+		if err := ValidateIBAN(iban); err != nil {
+			return newErr("invalid payer IBAN: %w", err)
+		}
+
+		pmt := &painPaymentInfo{
+			PmtInfID: fmt.Sprintf("%s-%d", msgID, grIdx+1),
+			PmtMtd:   "TRF",
+			NbOfTxs:  len(gr.Items),
+			SvcLvlCd: "SEPA",
+			Dbtr:     painParty{or.Client.Name},
+			DbtrAcct: painAccount{normalizeIBAN(iban)},
+			DbtrAgt:  newPainAgent(gr.Payer.BIC, version),
+			ChrgBr:   "SLEV",
+		}
+		if version == Pain001V03 {
+			pmt.ReqdExctnDt.Date = gr.DueDate.Format("2006-01-02")
+		} else {
+			pmt.ReqdExctnDt.Dt = gr.DueDate.Format("2006-01-02")
+		}
+
+		var grTotal int64
+		for _, it := range gr.Items {
+			tx, err := it.painTransaction(version)
+			if err != nil {
+				return err
+			}
+			pmt.CdtTrfTxInf = append(pmt.CdtTrfTxInf, tx)
+			grTotal += ToHalere(it.Amount)
+		}
+		if len(pmt.CdtTrfTxInf) == 0 {
+			continue
+		}
+		pmt.CtrlSum = formatAmount(float64(grTotal) / 100)
+
+		doc.Initn.PmtInf = append(doc.Initn.PmtInf, pmt)
+		doc.Initn.GrpHdr.NbOfTxs += len(pmt.CdtTrfTxInf)
+		total += grTotal
+	}
+
+	if len(doc.Initn.PmtInf) == 0 {
+		return newErr("no payments to export")
+	}
+	doc.Initn.GrpHdr.CtrlSum = formatAmount(float64(total) / 100)
+
+	if _, err := io.WriteString(wr, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(wr)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return newErr("unable to encode %s: %w", version, err)
+	}
+
+	_, err := io.WriteString(wr, "\n")
+	return err
+}
@@ -0,0 +1,104 @@
+package abo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validateXML validates the document against the official ISO 20022 schema using xmllint
+// in a subtest. Schemas are read from ABO_XSD_DIR, test/ by default, and the subtest is skipped
+// if the schema is missing. Missing xmllint skips the subtest too, except in CI.
+func validateXML(t *testing.T, doc []byte, xsd string) {
+	t.Run(xsd, func(t *testing.T) {
+		xmllint, err := exec.LookPath("xmllint")
+		if err != nil {
+			if os.Getenv("CI") != "" {
+				t.Fatal("xmllint is required for schema validation in CI")
+			}
+			t.Skip("xmllint not found, skipping schema validation")
+		}
+
+		xsdDir := os.Getenv("ABO_XSD_DIR")
+		if xsdDir == "" {
+			xsdDir = "test"
+		}
+		schema := filepath.Join(xsdDir, xsd)
+		if _, err := os.Stat(schema); err != nil {
+			t.Skipf("official schema %s not found, skipping schema validation", schema)
+		}
+
+		path := filepath.Join(t.TempDir(), "doc.xml")
+		if err := os.WriteFile(path, doc, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(xmllint, "--noout", "--schema", schema, path).CombinedOutput() //nolint:gosec
+		if err != nil {
+			t.Fatalf("%s validation failed: %s\n%s", xsd, out, doc)
+		}
+	})
+}
+
+func sepaTestOrder() *Order {
+	o := new(Order)
+	o.CreationDate = time.Date(2024, 9, 18, 10, 30, 0, 0, time.UTC)
+	o.Client.Name = "Firma s.r.o."
+	o.Client.BankCode = 800
+
+	gr := o.AddGroup(19, 2000145399, time.Date(2024, 9, 19, 0, 0, 0, 0, time.UTC))
+	gr.Payer.BIC = "GIBACZPX"
+
+	it := gr.AddItem(0, 0, 0, 1500.5, 1234, 308, 0, "Invoice 2024/15")
+	it.Recipient.Name = "Lieferant GmbH"
+	it.Recipient.IBAN = "DE89 3704 0044 0532 0130 00"
+	it.Recipient.BIC = "COBADEFFXXX"
+
+	it = gr.AddItemSimple(0, 1900133399, 2010, 99.99, 0, "")
+	it.Recipient.Name = "Dodavatel"
+	it.EndToEndID = "E2E-1"
+
+	return o
+}
+
+func TestOrderWritePain001(t *testing.T) {
+	for _, version := range []Pain001Version{Pain001V03, Pain001V09} {
+		buff := new(bytes.Buffer)
+		if err := sepaTestOrder().WritePain001(buff, version); err != nil {
+			t.Fatal(err)
+		}
+
+		validateXML(t, buff.Bytes(), string(version)+".xsd")
+
+		var doc struct {
+			CtrlSum  string   `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+			DbtrIBAN string   `xml:"CstmrCdtTrfInitn>PmtInf>DbtrAcct>Id>IBAN"`
+			E2E      []string `xml:"CstmrCdtTrfInitn>PmtInf>CdtTrfTxInf>PmtId>EndToEndId"`
+			IBANs    []string `xml:"CstmrCdtTrfInitn>PmtInf>CdtTrfTxInf>CdtrAcct>Id>IBAN"`
+		}
+		if err := xml.Unmarshal(buff.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.CtrlSum != "1600.49" || doc.DbtrIBAN != "CZ6508000000192000145399" {
+			t.Fatalf("bad group header or debtor in %s", version)
+		}
+		if strings.Join(doc.E2E, ",") != "/VS/1234/SS/0/KS/308,E2E-1" {
+			t.Fatalf("bad end-to-end IDs %v", doc.E2E)
+		}
+		if doc.IBANs[0] != "DE89370400440532013000" || doc.IBANs[1] != (Account{0, 1900133399, 2010}).IBAN() {
+			t.Fatalf("bad creditor IBANs %v", doc.IBANs)
+		}
+	}
+
+	o := sepaTestOrder()
+	o.Groups[0].Items[0].Recipient.Name = ""
+	if err := o.WritePain001(new(bytes.Buffer), Pain001V03); err == nil {
+		t.Fatal("expected error for missing recipient name")
+	}
+}
@@ -0,0 +1,176 @@
+package abo
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/k3a/ago/abo/currency"
+)
+
+// PohodaOptions controls export of statements to Pohoda XML
+type PohodaOptions struct {
+	// ICO is the company identification number of the accounting unit, required by Pohoda
+	ICO string
+	// BankAccount is the abbreviation of the bank account in Pohoda, default account if empty
+	BankAccount string
+	// Application is written as the data pack originator, "ago" if empty
+	Application string
+}
+
+// Pohoda XML writing structures in the element order of bank.xsd version 2.0
+
+const (
+	pohodaDataNamespace = "http://www.stormware.cz/schema/version_2/data.xsd"
+	pohodaBankNamespace = "http://www.stormware.cz/schema/version_2/bank.xsd"
+	pohodaTypeNamespace = "http://www.stormware.cz/schema/version_2/type.xsd"
+)
+
+type pohodaDataPack struct {
+	XMLName     xml.Name         `xml:"dat:dataPack"`
+	XmlnsDat    string           `xml:"xmlns:dat,attr"`
+	XmlnsBnk    string           `xml:"xmlns:bnk,attr"`
+	XmlnsTyp    string           `xml:"xmlns:typ,attr"`
+	Version     string           `xml:"version,attr"`
+	ID          string           `xml:"id,attr"`
+	ICO         string           `xml:"ico,attr"`
+	Application string           `xml:"application,attr"`
+	Note        string           `xml:"note,attr"`
+	Items       []pohodaPackItem `xml:"dat:dataPackItem"`
+}
+
+type pohodaPackItem struct {
+	Version string     `xml:"version,attr"`
+	ID      string     `xml:"id,attr"`
+	Bank    pohodaBank `xml:"bnk:bank"`
+}
+
+type pohodaBank struct {
+	Version string            `xml:"version,attr"`
+	Header  pohodaBankHeader  `xml:"bnk:bankHeader"`
+	Summary pohodaBankSummary `xml:"bnk:bankSummary"`
+}
+
+type pohodaStatementNumber struct {
+	StatementNumber string `xml:"bnk:statementNumber"`
+	NumberMovement  string `xml:"bnk:numberMovement"`
+}
+
+type pohodaBankHeader struct {
+	BankType        string                 `xml:"bnk:bankType"`
+	Account         string                 `xml:"bnk:account>typ:ids,omitempty"`
+	StatementNumber *pohodaStatementNumber `xml:"bnk:statementNumber"`
+	SymVar          string                 `xml:"bnk:symVar,omitempty"`
+	DateStatement   string                 `xml:"bnk:dateStatement"`
+	DatePayment     string                 `xml:"bnk:datePayment"`
+	Text            string                 `xml:"bnk:text"`
+	Company         string                 `xml:"bnk:partnerIdentity>typ:address>typ:company,omitempty"`
+	PaymentAccount  *pohodaPaymentAccount  `xml:"bnk:paymentAccount"`
+	SymConst        string                 `xml:"bnk:symConst,omitempty"`
+	SymSpec         string                 `xml:"bnk:symSpec,omitempty"`
+}
+
+type pohodaPaymentAccount struct {
+	AccountNo string `xml:"typ:accountNo"`
+	BankCode  string `xml:"typ:bankCode,omitempty"`
+}
+
+type pohodaForeignCurrency struct {
+	Currency string `xml:"typ:currency>typ:ids"`
+	PriceSum string `xml:"typ:priceSum"`
+}
+
+type pohodaBankSummary struct {
+	PriceNone       string                 `xml:"bnk:homeCurrency>typ:priceNone,omitempty"`
+	ForeignCurrency *pohodaForeignCurrency `xml:"bnk:foreignCurrency"`
+}
+
+func (s *Statement) pohodaBank(idx int, txn *Transaction, ccy currency.Currency, opts *PohodaOptions) pohodaBank {
+	if txn.Currency != currency.Unknown {
+		ccy = txn.Currency
+	}
+
+	h := pohodaBankHeader{
+		BankType:      "receipt",
+		Account:       opts.BankAccount,
+		DateStatement: txn.DueDate.Format("2006-01-02"),
+		DatePayment:   txn.DueDate.Format("2006-01-02"),
+		Text:          truncRunes(txn.payee(), 240),
+		Company:       truncRunes(txn.Recipient.Name, 255),
+	}
+	if txn.IsDebit() {
+		h.BankType = "expense"
+	}
+	if s.Info.StatementNumber != 0 {
+		h.StatementNumber = &pohodaStatementNumber{strconv.Itoa(s.Info.StatementNumber), strconv.Itoa(idx + 1)}
+	}
+	if txn.VS != 0 {
+		h.SymVar = strconv.Itoa(txn.VS)
+	}
+	if txn.KS != 0 {
+		h.SymConst = fmt.Sprintf("%04d", txn.KS)
+	}
+	if txn.SS != 0 {
+		h.SymSpec = strconv.Itoa(txn.SS)
+	}
+	if txn.Recipient.AccountNum != 0 {
+		h.PaymentAccount = &pohodaPaymentAccount{
+			AccountNo: Account{Prefix: txn.Recipient.AccountNumPrefix, Number: txn.Recipient.AccountNum}.String(),
+		}
+		if txn.Recipient.BankCode != 0 {
+			h.PaymentAccount.BankCode = fmt.Sprintf("%04d", txn.Recipient.BankCode)
+		}
+	}
+
+	b := pohodaBank{Version: "2.0", Header: h}
+	if ccy == currency.CZK {
+		b.Summary.PriceNone = formatAmount(txn.Amount)
+	} else {
+		b.Summary.ForeignCurrency = &pohodaForeignCurrency{ccy.String(), formatAmount(txn.Amount)}
+	}
+
+	return b
+}
+
+// WritePohoda writes the statement as Pohoda XML data pack of bank documents (bnk:bank),
+// one receipt or expense per transaction. Amounts in currencies other than CZK are written
+// as foreign currency amounts.
+func (s *Statement) WritePohoda(wr io.Writer, opts PohodaOptions) error {
+	if opts.ICO == "" {
+		return newErr("ICO is required by Pohoda XML")
+	}
+
+	ccy := s.currency()
+	pack := pohodaDataPack{
+		XmlnsDat:    pohodaDataNamespace,
+		XmlnsBnk:    pohodaBankNamespace,
+		XmlnsTyp:    pohodaTypeNamespace,
+		Version:     "2.0",
+		ID:          fmt.Sprintf("%d-%03d", s.Info.AccountNumber, s.Info.StatementNumber),
+		ICO:         opts.ICO,
+		Application: orDefault(opts.Application, "ago"),
+		Note:        "Bank statement " + accountFromNumber(s.Info.AccountNumber).String(),
+	}
+
+	for i, txn := range s.Transactions {
+		pack.Items = append(pack.Items, pohodaPackItem{
+			Version: "2.0",
+			ID:      s.fitID(i, txn),
+			Bank:    s.pohodaBank(i, txn, ccy, &opts),
+		})
+	}
+
+	if _, err := io.WriteString(wr, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(wr)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pack); err != nil {
+		return newErr("unable to encode Pohoda XML: %w", err)
+	}
+
+	_, err := io.WriteString(wr, "\n")
+	return err
+}
@@ -0,0 +1,29 @@
+package abo
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestStatementWritePohoda(t *testing.T) {
+	s := readTestStatement(t)
+
+	buff := new(bytes.Buffer)
+	if err := s.WritePohoda(buff, PohodaOptions{}); err == nil {
+		t.Fatal("missing ICO not rejected")
+	}
+
+	buff.Reset()
+	if err := s.WritePohoda(buff, PohodaOptions{ICO: "12345678", BankAccount: "FIO"}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := os.ReadFile("./test/pohoda.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buff.Bytes(), exp) {
+		t.Fatalf("unexpected Pohoda XML\n%s", buff.String())
+	}
+}
@@ -0,0 +1,492 @@
+// Package qr is a minimal QR code encoder supporting byte mode data
+// in versions 1 to 40 and all error correction levels.
+package qr
+
+import (
+	"fmt"
+)
+
+func newErr(format string, args ...interface{}) error {
+	return fmt.Errorf("qr: "+format, args...)
+}
+
+// Level is an error correction level
+type Level int
+
+// Error correction levels recovering about 7, 15, 25 and 30 % of the code
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+// formatBits returns the level bits of the format information
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// error correction codewords per block, indexed by level and version
+var eccPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// number of error correction blocks, indexed by level and version
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// rawModules returns the number of modules available for data and error correction
+func rawModules(ver int) int {
+	n := (16*ver+128)*ver + 64
+	if ver >= 2 {
+		align := ver/7 + 2
+		n -= (25*align-10)*align - 55
+		if ver >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// dataCodewords returns the number of data codewords of the version and level
+func dataCodewords(ver int, level Level) int {
+	return rawModules(ver)/8 - eccPerBlock[level][ver]*eccBlocks[level][ver]
+}
+
+// Code is an encoded QR code
+type Code struct {
+	// Size is the number of modules on a side
+	Size    int
+	Version int
+	Level   Level
+	modules []bool
+	isFunc  []bool
+}
+
+// Black reports whether the module at column x and row y is dark,
+// modules outside of the code are light
+func (c *Code) Black(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y*c.Size+x]
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+}
+
+func (c *Code) setFunc(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+	c.isFunc[y*c.Size+x] = true
+}
+
+// Encode encodes data in byte mode using the smallest version fitting the data
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < L || level > H {
+		return nil, newErr("invalid error correction level %d", level)
+	}
+
+	ver := 1
+	for ; ver <= 40; ver++ {
+		if 4+countBits(ver)+8*len(data) <= dataCodewords(ver, level)*8 {
+			break
+		}
+	}
+	if ver > 40 {
+		return nil, newErr("%d bytes of data don't fit QR code", len(data))
+	}
+
+	c := &Code{Size: ver*4 + 17, Version: ver, Level: level}
+	c.modules = make([]bool, c.Size*c.Size)
+	c.isFunc = make([]bool, c.Size*c.Size)
+
+	c.drawFunctionPatterns()
+	c.drawCodewords(addECC(encodeData(data, ver, level), ver, level))
+
+	// choose the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+
+	return c, nil
+}
+
+// countBits returns the length of byte mode character count indicator
+func countBits(ver int) int {
+	if ver <= 9 {
+		return 8
+	}
+	return 16
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
+
+// encodeData returns padded data codewords of byte mode segment
+func encodeData(data []byte, ver int, level Level) []byte {
+	capacity := dataCodewords(ver, level) * 8
+
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), countBits(ver))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	// terminator and padding to bytes
+	term := capacity - len(bb)
+	if term > 4 {
+		term = 4
+	}
+	bb.append(0, term)
+	bb.append(0, (8-len(bb)%8)%8)
+
+	out := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bb); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bb[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		hi := z >> 7
+		z = (z << 1) ^ (hi * 0x1D)
+		z ^= ((y >> uint(i)) & 1) * x
+	}
+	return z
+}
+
+// rsDivisor returns Reed-Solomon generator polynomial of the degree without the leading term
+func rsDivisor(degree int) []byte {
+	div := make([]byte, degree)
+	div[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range div {
+			div[j] = gfMul(div[j], root)
+			if j+1 < len(div) {
+				div[j] ^= div[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return div
+}
+
+// rsRemainder returns Reed-Solomon error correction codewords of the data
+func rsRemainder(data, div []byte) []byte {
+	rem := make([]byte, len(div))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i := range rem {
+			rem[i] ^= gfMul(div[i], factor)
+		}
+	}
+	return rem
+}
+
+// addECC splits data into blocks, adds error correction and interleaves them
+func addECC(data []byte, ver int, level Level) []byte {
+	numBlocks := eccBlocks[level][ver]
+	eccLen := eccPerBlock[level][ver]
+	raw := rawModules(ver) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	div := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		dat := data[k : k+n]
+		k += n
+
+		block := append([]byte{}, dat...)
+		if i < numShort {
+			// placeholder keeping all blocks of equal length
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, rsRemainder(dat, div)...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// alignmentPositions returns centers of alignment patterns
+func alignmentPositions(ver int) []int {
+	if ver == 1 {
+		return nil
+	}
+	num := ver/7 + 2
+	step := (ver*8 + num*3 + 5) / (num*4 - 4) * 2
+
+	pos := make([]int, num)
+	pos[0] = 6
+	for i, p := num-1, ver*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+func (c *Code) drawFunctionPatterns() {
+	// timing patterns
+	for i := 0; i < c.Size; i++ {
+		c.setFunc(6, i, i%2 == 0)
+		c.setFunc(i, 6, i%2 == 0)
+	}
+
+	// finder patterns with separators
+	for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.setFunc(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	// alignment patterns except those overlapping finders
+	pos := alignmentPositions(c.Version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.setFunc(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve format information areas
+	c.drawFormatBits(0)
+
+	// version information
+	if c.Version >= 7 {
+		rem := c.Version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := c.Version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 != 0
+			a, b := c.Size-11+i%3, i/3
+			c.setFunc(a, b, dark)
+			c.setFunc(b, a, dark)
+		}
+	}
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	data := c.Level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 != 0
+	}
+
+	// around the top left finder
+	for i := 0; i <= 5; i++ {
+		c.setFunc(8, i, bit(i))
+	}
+	c.setFunc(8, 7, bit(6))
+	c.setFunc(8, 8, bit(7))
+	c.setFunc(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunc(14-i, 8, bit(i))
+	}
+
+	// copy next to the other finders
+	for i := 0; i < 8; i++ {
+		c.setFunc(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunc(8, c.Size-15+i, bit(i))
+	}
+	c.setFunc(8, c.Size-8, true)
+}
+
+// drawCodewords places codewords in the zigzag order skipping function patterns
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunc[y*c.Size+x] && i < len(data)*8 {
+					c.set(x, y, (data[i>>3]>>uint(7-i&7))&1 != 0)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts data modules selected by the mask, applying it twice reverts it
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var inv bool
+			switch mask {
+			case 0:
+				inv = (x+y)%2 == 0
+			case 1:
+				inv = y%2 == 0
+			case 2:
+				inv = x%3 == 0
+			case 3:
+				inv = (x+y)%3 == 0
+			case 4:
+				inv = (x/3+y/2)%2 == 0
+			case 5:
+				inv = x*y%2+x*y%3 == 0
+			case 6:
+				inv = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				inv = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if inv && !c.isFunc[y*c.Size+x] {
+				c.modules[y*c.Size+x] = !c.modules[y*c.Size+x]
+			}
+		}
+	}
+}
+
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores the code by the rules of ISO/IEC 18004 mask evaluation
+func (c *Code) penalty() int {
+	n := c.Size
+	p := 0
+
+	for pass := 0; pass < 2; pass++ {
+		at := func(i, j int) bool {
+			if pass == 0 {
+				return c.Black(j, i)
+			}
+			return c.Black(i, j)
+		}
+		for i := 0; i < n; i++ {
+			// runs of five and more modules of the same color
+			run := 1
+			for j := 1; j <= n; j++ {
+				if j < n && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += run - 2
+				}
+				run = 1
+			}
+			// finder-like patterns
+			for j := 0; j+11 <= n; j++ {
+				for _, pat := range finderLike {
+					match := true
+					for k, dark := range pat {
+						if at(i, j+k) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						p += 40
+					}
+				}
+			}
+		}
+	}
+
+	// 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			b := c.Black(x, y)
+			if b {
+				dark++
+			}
+			if x+1 < n && y+1 < n && b == c.Black(x+1, y) && b == c.Black(x, y+1) && b == c.Black(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+
+	// balance of dark and light modules
+	p += abs(dark*20-n*n*10) / (n * n) * 10
+
+	return p
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
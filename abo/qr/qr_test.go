@@ -0,0 +1,65 @@
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tc := range []struct {
+		n       int
+		level   Level
+		version int
+	}{
+		{14, M, 1},
+		{15, M, 2},
+		{17, L, 1},
+		{106, M, 6},
+		{107, M, 7},
+		{2331, M, 40},
+		{1273, H, 40},
+	} {
+		c, err := Encode(bytes.Repeat([]byte("a"), tc.n), tc.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Version != tc.version || c.Size != tc.version*4+17 {
+			t.Fatalf("%d bytes at level %d: expected version %d, got %d", tc.n, tc.level, tc.version, c.Version)
+		}
+
+		// finder pattern corners and the dark module
+		for _, p := range [][2]int{{0, 0}, {c.Size - 1, 0}, {0, c.Size - 1}, {8, c.Size - 8}} {
+			if !c.Black(p[0], p[1]) {
+				t.Fatalf("module %v is not dark", p)
+			}
+		}
+		if c.Black(7, 7) || c.Black(-1, 0) || c.Black(0, c.Size) {
+			t.Fatal("separator or outside module is dark")
+		}
+
+		// both copies of format information are equal
+		for i := 0; i < 8; i++ {
+			a := c.Black(8, []int{0, 1, 2, 3, 4, 5, 7, 8}[i])
+			if a != c.Black(c.Size-1-i, 8) {
+				t.Fatalf("format information bit %d differs", i)
+			}
+		}
+	}
+
+	if _, err := Encode(make([]byte, 2332), M); err == nil {
+		t.Fatal("too long data not rejected")
+	}
+	if _, err := Encode(nil, Level(4)); err == nil {
+		t.Fatal("invalid level not rejected")
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// version 1-M codewords of "01234567" in numeric mode from ISO/IEC 18004 annex I
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	exp := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+	if ecc := rsRemainder(data, rsDivisor(10)); !bytes.Equal(ecc, exp) {
+		t.Fatalf("expected % X, got % X", exp, ecc)
+	}
+}
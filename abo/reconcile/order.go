@@ -0,0 +1,155 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/k3a/ago/abo"
+)
+
+// ItemStatus is the execution status of a payment order item
+type ItemStatus int
+
+// Item statuses
+const (
+	// UnknownItemStatus of an item not matched against statements
+	UnknownItemStatus ItemStatus = iota
+	// Executed item found in a statement
+	Executed
+	// Pending item not found yet as statements don't cover its due date
+	Pending
+	// Missing item not found although statements cover its due date
+	Missing
+)
+
+func (s ItemStatus) String() string {
+	switch s {
+	case Executed:
+		return "executed"
+	case Pending:
+		return "pending"
+	case Missing:
+		return "missing"
+	}
+	return "unknown"
+}
+
+// OrderOptions controls matching of order items to statements
+type OrderOptions struct {
+	// MaxDelay is the number of days after the due date the item may be executed,
+	// e.g. when due on a bank holiday
+	MaxDelay int
+}
+
+// ItemResult is the execution status of a payment order item
+type ItemResult struct {
+	Group  *abo.Group
+	Item   *abo.Item
+	Status ItemStatus
+	// Transaction executing the item
+	Transaction *abo.Transaction
+}
+
+// OrderReport describes execution of a payment order
+type OrderReport struct {
+	Items []ItemResult
+}
+
+// Exceptions returns items which were not executed
+func (r *OrderReport) Exceptions() []ItemResult {
+	var res []ItemResult
+	for _, ir := range r.Items {
+		if ir.Status != Executed {
+			res = append(res, ir)
+		}
+	}
+	return res
+}
+
+// WriteExceptions writes a line for each item which was not executed
+func (r *OrderReport) WriteExceptions(wr io.Writer) error {
+	for _, ir := range r.Exceptions() {
+		it := ir.Item
+		acc := abo.Account{Prefix: it.Recipient.AccountNumPrefix, Number: it.Recipient.AccountNum, BankCode: it.Recipient.BankCode}
+		_, err := fmt.Fprintf(wr, "%-8s %s %-24s %12.2f VS:%d KS:%d SS:%d %s\n", ir.Status,
+			ir.Group.DueDate.Format("2006-01-02"), acc, it.Amount, it.VS, it.KS, it.SS, it.MessageForRecipient)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coversDays reports whether each day from..to is covered by a statement
+func coversDays(stmts []*abo.Statement, from, to time.Time) bool {
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		found := false
+		for _, s := range stmts {
+			if !abo.CalendarDay(s.Info.StartDate).After(d) && !abo.CalendarDay(s.Info.EndDate).Before(d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func itemMatch(it *abo.Item, txn *abo.Transaction) bool {
+	return txn.Type == abo.TypeDebit &&
+		txn.Recipient.AccountNumPrefix == it.Recipient.AccountNumPrefix &&
+		txn.Recipient.AccountNum == it.Recipient.AccountNum &&
+		txn.Recipient.BankCode == it.Recipient.BankCode &&
+		abo.ToHalere(txn.Amount) == abo.ToHalere(it.Amount) &&
+		txn.VS == it.VS
+}
+
+// MatchOrder finds executed items of the order in statements of the payer accounts.
+// An item is executed by a debit to the recipient account with the same amount and VS
+// booked between the due date and MaxDelay days later. Each transaction executes one item.
+func MatchOrder(or *abo.Order, stmts []*abo.Statement, opts OrderOptions) *OrderReport {
+	used := make(map[*abo.Transaction]bool)
+	rep := new(OrderReport)
+
+	for _, gr := range or.Groups {
+		payer := gr.Payer.AccountNumPrefix*10000000000 + gr.Payer.AccountNum
+		from := abo.CalendarDay(gr.DueDate)
+		to := from.AddDate(0, 0, opts.MaxDelay)
+
+		var payerStmts []*abo.Statement
+		for _, s := range stmts {
+			if s.Info.AccountNumber == payer {
+				payerStmts = append(payerStmts, s)
+			}
+		}
+		covered := coversDays(payerStmts, from, to)
+
+		for _, it := range gr.Items {
+			ir := ItemResult{Group: gr, Item: it, Status: Pending}
+			if covered {
+				ir.Status = Missing
+			}
+
+		search:
+			for _, s := range payerStmts {
+				for _, txn := range s.Transactions {
+					d := abo.CalendarDay(txn.DueDate)
+					if used[txn] || d.Before(from) || d.After(to) || !itemMatch(it, txn) {
+						continue
+					}
+					used[txn] = true
+					ir.Status = Executed
+					ir.Transaction = txn
+					break search
+				}
+			}
+
+			rep.Items = append(rep.Items, ir)
+		}
+	}
+
+	return rep
+}
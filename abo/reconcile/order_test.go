@@ -0,0 +1,87 @@
+package reconcile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3a/ago/abo"
+)
+
+func TestMatchOrder(t *testing.T) {
+	day := time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+	payer := abo.Account{Prefix: 19, Number: 2101135843}
+	recp := abo.Account{Number: 1900133399, BankCode: 2010}
+
+	or := new(abo.Order)
+	executed := or.AddPayment(payer, recp, 100, abo.Symbols{VS: 1}, day, "")
+	late := or.AddPayment(payer, recp, 200, abo.Symbols{VS: 2}, day, "")
+	missing := or.AddPayment(payer, recp, 300, abo.Symbols{VS: 3}, day, "")
+	pending := or.AddPayment(payer, recp, 400, abo.Symbols{VS: 4}, day.AddDate(0, 0, 5), "")
+
+	debit := func(amount float64, vs int, date time.Time) *abo.Transaction {
+		txn := &abo.Transaction{Amount: amount, VS: vs, Type: abo.TypeDebit, DueDate: date}
+		txn.Recipient.AccountNum = recp.Number
+		txn.Recipient.BankCode = recp.BankCode
+		return txn
+	}
+
+	s1 := new(abo.Statement)
+	s1.Info.AccountNumber = 192101135843
+	s1.Info.StartDate = day.AddDate(0, 0, -1)
+	s1.Info.EndDate = day
+	s1.Transactions = []*abo.Transaction{
+		debit(100, 1, day),
+		debit(300, 3, day.AddDate(0, 0, -1)),
+	}
+	s2 := new(abo.Statement)
+	s2.Info.AccountNumber = s1.Info.AccountNumber
+	s2.Info.StartDate = day.AddDate(0, 0, 1)
+	s2.Info.EndDate = day.AddDate(0, 0, 2)
+	s2.Transactions = []*abo.Transaction{
+		debit(200, 2, day.AddDate(0, 0, 1)),
+		debit(100, 1, day.AddDate(0, 0, 1)),
+	}
+	other := new(abo.Statement)
+	other.Info.AccountNumber = 2101135843
+	other.Info.StartDate = day
+	other.Info.EndDate = day
+	other.Transactions = []*abo.Transaction{debit(300, 3, day)}
+
+	rep := MatchOrder(or, []*abo.Statement{s1, s2, other}, OrderOptions{MaxDelay: 2})
+
+	exp := map[*abo.Item]ItemStatus{executed: Executed, late: Executed, missing: Missing, pending: Pending}
+	if len(rep.Items) != len(exp) {
+		t.Fatalf("expected %d items, got %d", len(exp), len(rep.Items))
+	}
+	for _, ir := range rep.Items {
+		if ir.Status != exp[ir.Item] {
+			t.Fatalf("item VS %d: expected %s, got %s", ir.Item.VS, exp[ir.Item], ir.Status)
+		}
+	}
+	if rep.Items[0].Transaction != s1.Transactions[0] {
+		t.Fatal("item matched to a wrong transaction")
+	}
+
+	var buf bytes.Buffer
+	if err := rep.WriteExceptions(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "missing  2024-09-18 1900133399/2010") || !strings.HasPrefix(lines[1], "pending") {
+		t.Fatalf("unexpected exceptions\n%s", buf.String())
+	}
+
+	// without delay the late item is missing
+	rep = MatchOrder(or, []*abo.Statement{s1, s2}, OrderOptions{})
+	if rep.Items[1].Status != Missing {
+		t.Fatalf("expected late item missing, got %s", rep.Items[1].Status)
+	}
+
+	// an uninitialised result is an exception
+	rep = &OrderReport{Items: []ItemResult{{}}}
+	if len(rep.Exceptions()) != 1 || rep.Items[0].Status.String() != "unknown" {
+		t.Fatal("zero item status must not be executed")
+	}
+}
@@ -0,0 +1,296 @@
+// Package reconcile matches statement transactions to expected receivables
+// and to items of submitted payment orders.
+package reconcile
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/k3a/ago/abo"
+	"github.com/k3a/ago/abo/currency"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Receivable is an expected incoming payment, e.g. an issued invoice
+type Receivable struct {
+	// ID is the caller's reference of the receivable, e.g. invoice number
+	ID     string
+	VS     int
+	SS     int
+	Amount float64
+	// Currency of the amount, CZK if unknown. Only payments in the same currency match.
+	Currency currency.Currency
+	// Counterparty account and Name of the payer are optional, used to find payments with wrong VS
+	Counterparty abo.Account
+	Name         string
+}
+
+// Status is the result of matching a receivable
+type Status int
+
+// Match statuses
+const (
+	// UnknownStatus of a receivable not matched against payments
+	UnknownStatus Status = iota
+	// Exact payment of the receivable
+	Exact
+	// Partial payment, less than expected was paid
+	Partial
+	// Overpaid, more than expected was paid
+	Overpaid
+	// WrongVS payment matched by amount and counterparty with missing or different VS
+	WrongVS
+)
+
+func (s Status) String() string {
+	switch s {
+	case Exact:
+		return "exact"
+	case Partial:
+		return "partial"
+	case Overpaid:
+		return "overpaid"
+	case WrongVS:
+		return "wrong VS"
+	}
+	return "unknown"
+}
+
+// Match is a receivable with its payments
+type Match struct {
+	Receivable   *Receivable
+	Transactions []*abo.Transaction
+	Status       Status
+	// Paid is the sum of the matched transactions
+	Paid float64
+}
+
+// Result of the reconciliation
+type Result struct {
+	Matches []Match
+	// Unpaid receivables without any payment
+	Unpaid []*Receivable
+	// Unmatched credit transactions
+	Unmatched []*abo.Transaction
+}
+
+type reconciler struct {
+	recs []*Receivable
+	txns []*abo.Transaction
+	used map[*abo.Transaction]bool
+	paid map[*Receivable]*Match
+}
+
+// orCZK returns CZK for unknown currency
+func orCZK(ccy currency.Currency) currency.Currency {
+	if ccy == currency.Unknown {
+		return currency.CZK
+	}
+	return ccy
+}
+
+func currencyMatch(rec *Receivable, txn *abo.Transaction) bool {
+	return orCZK(rec.Currency) == orCZK(txn.Currency)
+}
+
+func (r *reconciler) symbolsMatch(rec *Receivable, txn *abo.Transaction) bool {
+	return rec.VS != 0 && rec.VS == txn.VS && (rec.SS == 0 || rec.SS == txn.SS) && currencyMatch(rec, txn)
+}
+
+// sharesSymbols reports whether a later unpaid receivable may take payments of rec
+func (r *reconciler) sharesSymbols(idx int) bool {
+	rec := r.recs[idx]
+	for _, other := range r.recs[idx+1:] {
+		if r.paid[other] == nil && other.VS == rec.VS && (other.SS == 0 || rec.SS == 0 || other.SS == rec.SS) &&
+			orCZK(other.Currency) == orCZK(rec.Currency) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reconciler) add(rec *Receivable, status Status, txns ...*abo.Transaction) {
+	m := &Match{Receivable: rec, Transactions: txns, Status: status}
+	var paid int64
+	for _, txn := range txns {
+		r.used[txn] = true
+		paid += abo.ToHalere(txn.Amount)
+	}
+	m.Paid = float64(paid) / 100
+
+	if status != WrongVS {
+		switch exp := abo.ToHalere(rec.Amount); {
+		case paid < exp:
+			m.Status = Partial
+		case paid > exp:
+			m.Status = Overpaid
+		}
+	}
+	r.paid[rec] = m
+}
+
+// matchExact matches receivables to single payments with the same symbols and amount
+func (r *reconciler) matchExact() {
+	for _, rec := range r.recs {
+		for _, txn := range r.txns {
+			if !r.used[txn] && r.symbolsMatch(rec, txn) && abo.ToHalere(txn.Amount) == abo.ToHalere(rec.Amount) {
+				r.add(rec, Exact, txn)
+				break
+			}
+		}
+	}
+}
+
+// matchSymbols matches remaining receivables to remaining payments with the same symbols.
+// If later receivables share the symbols, payments are assigned up to the outstanding amount
+// and the last receivable takes the rest.
+func (r *reconciler) matchSymbols() {
+	for i, rec := range r.recs {
+		if r.paid[rec] != nil {
+			continue
+		}
+		shared := r.sharesSymbols(i)
+		outstanding := abo.ToHalere(rec.Amount)
+
+		var txns []*abo.Transaction
+		for _, txn := range r.txns {
+			if r.used[txn] || !r.symbolsMatch(rec, txn) {
+				continue
+			}
+			amount := abo.ToHalere(txn.Amount)
+			if shared && amount > outstanding {
+				continue
+			}
+			txns = append(txns, txn)
+			outstanding -= amount
+		}
+		if len(txns) > 0 {
+			r.add(rec, Exact, txns...)
+		}
+	}
+}
+
+// matchFuzzy matches unpaid receivables to payments of the same amount from the expected
+// counterparty account or name. Without counterparty the amount must be unique
+// among both unpaid receivables and unmatched payments.
+func (r *reconciler) matchFuzzy() {
+	recCount := make(map[int64]int)
+	for _, rec := range r.recs {
+		if r.paid[rec] == nil {
+			recCount[abo.ToHalere(rec.Amount)]++
+		}
+	}
+	txnCount := make(map[int64]int)
+	for _, txn := range r.txns {
+		if !r.used[txn] {
+			txnCount[abo.ToHalere(txn.Amount)]++
+		}
+	}
+
+	for _, rec := range r.recs {
+		if r.paid[rec] != nil {
+			continue
+		}
+		amount := abo.ToHalere(rec.Amount)
+		for _, txn := range r.txns {
+			if r.used[txn] || abo.ToHalere(txn.Amount) != amount || !currencyMatch(rec, txn) {
+				continue
+			}
+			if counterpartyMatch(rec, txn) || (!hasCounterparty(rec) && recCount[amount] == 1 && txnCount[amount] == 1) {
+				r.add(rec, WrongVS, txn)
+				break
+			}
+		}
+	}
+}
+
+func hasCounterparty(rec *Receivable) bool {
+	return rec.Counterparty.Number != 0 || rec.Name != ""
+}
+
+func counterpartyMatch(rec *Receivable, txn *abo.Transaction) bool {
+	if rec.Counterparty.Number != 0 && rec.Counterparty.Number == txn.Recipient.AccountNum &&
+		rec.Counterparty.Prefix == txn.Recipient.AccountNumPrefix &&
+		(rec.Counterparty.BankCode == 0 || rec.Counterparty.BankCode == txn.Recipient.BankCode) {
+		return true
+	}
+	return rec.Name != "" && namesMatch(rec.Name, txn.Recipient.Name)
+}
+
+// nameTokens returns lowercase words of the name without diacritics
+func nameTokens(name string) []string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteByte(' ')
+		}
+	}
+	return strings.Fields(sb.String())
+}
+
+// namesMatch reports whether all words of the shorter name appear in the other name,
+// ignoring case, diacritics and punctuation
+func namesMatch(a, b string) bool {
+	ta, tb := nameTokens(a), nameTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return false
+	}
+	if len(ta) > len(tb) {
+		ta, tb = tb, ta
+	}
+
+	words := make(map[string]bool, len(tb))
+	for _, w := range tb {
+		words[w] = true
+	}
+	for _, w := range ta {
+		if !words[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// Reconcile matches incoming transactions to the receivables. Payments are matched
+// by VS (and SS if set in the receivable) preferring payments of the exact amount,
+// remaining payments are matched by amount and counterparty as payments with wrong VS.
+// Only credit transactions in the currency of the receivable are considered,
+// debits and reversals are ignored.
+func Reconcile(receivables []Receivable, txns []*abo.Transaction) *Result {
+	r := &reconciler{
+		used: make(map[*abo.Transaction]bool),
+		paid: make(map[*Receivable]*Match),
+	}
+	for i := range receivables {
+		r.recs = append(r.recs, &receivables[i])
+	}
+	for _, txn := range txns {
+		if txn.Type == abo.TypeCredit {
+			r.txns = append(r.txns, txn)
+		}
+	}
+
+	r.matchExact()
+	r.matchSymbols()
+	r.matchFuzzy()
+
+	res := new(Result)
+	for _, rec := range r.recs {
+		if m := r.paid[rec]; m != nil {
+			res.Matches = append(res.Matches, *m)
+		} else {
+			res.Unpaid = append(res.Unpaid, rec)
+		}
+	}
+	for _, txn := range r.txns {
+		if !r.used[txn] {
+			res.Unmatched = append(res.Unmatched, txn)
+		}
+	}
+
+	return res
+}
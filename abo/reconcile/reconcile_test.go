@@ -0,0 +1,100 @@
+package reconcile
+
+import (
+	"os"
+	"testing"
+
+	"github.com/k3a/ago/abo"
+	"github.com/k3a/ago/abo/currency"
+)
+
+func readPayments(t *testing.T) []*abo.Transaction {
+	f, err := os.Open("test/payments.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s, err := abo.FromCSV(f, abo.CSVMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Transactions
+}
+
+func TestReconcile(t *testing.T) {
+	recs := []Receivable{
+		{ID: "exact", VS: 1001, Amount: 100},
+		{ID: "partial", VS: 1002, Amount: 200},
+		{ID: "overpaid", VS: 1003, Amount: 300},
+		{ID: "by-name", VS: 1004, Amount: 400, Name: "Jiří Novák"},
+		{ID: "by-account", VS: 1005, Amount: 500, Counterparty: abo.Account{Number: 123456789, BankCode: 100}},
+		{ID: "by-amount", VS: 1006, Amount: 600},
+		{ID: "unpaid", VS: 1007, Amount: 700},
+		{ID: "shared-vs-1", VS: 1008, Amount: 100},
+		{ID: "shared-vs-2", VS: 1008, Amount: 200},
+		{ID: "other-currency", VS: 1010, Amount: 100, Currency: currency.CZK},
+	}
+
+	res := Reconcile(recs, readPayments(t))
+
+	exp := map[string]Status{
+		"exact":       Exact,
+		"partial":     Partial,
+		"overpaid":    Overpaid,
+		"by-name":     WrongVS,
+		"by-account":  WrongVS,
+		"by-amount":   WrongVS,
+		"shared-vs-1": Exact,
+		"shared-vs-2": Partial,
+	}
+	if len(res.Matches) != len(exp) {
+		t.Fatalf("expected %d matches, got %d", len(exp), len(res.Matches))
+	}
+	for _, m := range res.Matches {
+		if m.Status != exp[m.Receivable.ID] {
+			t.Fatalf("%s: expected %s, got %s", m.Receivable.ID, exp[m.Receivable.ID], m.Status)
+		}
+	}
+	if m := res.Matches[1]; m.Paid != 180 || len(m.Transactions) != 2 {
+		t.Fatalf("unexpected partial payment %+v", m)
+	}
+	if m := res.Matches[6]; m.Paid != 100 || len(m.Transactions) != 2 {
+		t.Fatalf("unexpected payment of the first receivable with shared VS %+v", m)
+	}
+	if m := res.Matches[7]; m.Paid != 150 || len(m.Transactions) != 1 {
+		t.Fatalf("unexpected payment of the second receivable with shared VS %+v", m)
+	}
+
+	// 700 is paid twice without VS so the amount is not unique, EUR payment doesn't pay CZK
+	if len(res.Unpaid) != 2 || res.Unpaid[0].ID != "unpaid" || res.Unpaid[1].ID != "other-currency" {
+		t.Fatalf("unexpected unpaid %+v", res.Unpaid)
+	}
+	if len(res.Unmatched) != 3 || res.Unmatched[0].Amount != 700 || res.Unmatched[2].Currency != currency.EUR {
+		t.Fatalf("unexpected unmatched %+v", res.Unmatched)
+	}
+}
+
+func TestStatusZero(t *testing.T) {
+	var m Match
+	if m.Status == Exact || m.Status.String() != "unknown" {
+		t.Fatalf("zero match status %s", m.Status)
+	}
+}
+
+func TestNamesMatch(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		ok   bool
+	}{
+		{"Jiří Novák", "NOVAK JIRI", true},
+		{"ACME s.r.o.", "Acme, s. r. o.", true},
+		{"ACME", "ACME s.r.o.", true},
+		{"Jan Novák", "Jana Nováková", false},
+		{"", "Novák", false},
+	} {
+		if namesMatch(tc.a, tc.b) != tc.ok {
+			t.Fatalf("namesMatch(%q, %q) != %v", tc.a, tc.b, tc.ok)
+		}
+	}
+}
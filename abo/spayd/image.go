@@ -0,0 +1,169 @@
+package spayd
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+
+	"github.com/k3a/ago/abo"
+	"github.com/k3a/ago/abo/qr"
+)
+
+// ImageOptions controls rendering of QR payment images
+type ImageOptions struct {
+	// Scale is the size of a QR code module in pixels, 4 if zero
+	Scale int
+	// Frame draws the frame with "QR Platba" caption around the code
+	Frame bool
+}
+
+const frameCaption = "QR Platba"
+
+// font is a 5x7 bitmap font of the caption characters, bit 4 is the leftmost pixel
+var font = map[rune][7]uint8{
+	' ': {},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'l': {0x0C, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'a': {0x00, 0x00, 0x0E, 0x01, 0x0F, 0x11, 0x0F},
+	't': {0x08, 0x08, 0x1C, 0x08, 0x08, 0x09, 0x06},
+	'b': {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1E},
+}
+
+// layout returns image size and dark rectangles of the QR payment
+func layout(code *qr.Code, opts ImageOptions) (image.Point, []image.Rectangle) {
+	s := opts.Scale
+	if s <= 0 {
+		s = 4
+	}
+	quiet := 4 * s
+	codePx := code.Size * s
+
+	var rects []image.Rectangle
+	addCode := func(x0, y0 int) {
+		for y := 0; y < code.Size; y++ {
+			for x := 0; x < code.Size; x++ {
+				if !code.Black(x, y) {
+					continue
+				}
+				// merge horizontal runs
+				run := x
+				for run+1 < code.Size && code.Black(run+1, y) {
+					run++
+				}
+				rects = append(rects, image.Rect(x0+x*s, y0+y*s, x0+(run+1)*s, y0+(y+1)*s))
+				x = run
+			}
+		}
+	}
+
+	if !opts.Frame {
+		addCode(quiet, quiet)
+		return image.Pt(codePx+2*quiet, codePx+2*quiet), rects
+	}
+
+	// caption glyph pixel is half of a module, the caption is centered on the bottom frame line
+	g := s / 2
+	if g < 1 {
+		g = 1
+	}
+	textW := (len(frameCaption)*6 - 1) * g
+	textH := 7 * g
+	line := s
+	margin := textH/2 + s
+
+	innerW := codePx + 2*quiet
+	if innerW < textW+6*s {
+		innerW = textW + 6*s
+	}
+	innerH := codePx + 2*quiet
+	size := image.Pt(2*(margin+line)+innerW, 2*(margin+line)+innerH)
+
+	left, top := margin, margin
+	right, bottom := size.X-margin, size.Y-margin
+	addCode(left+line+(innerW-codePx)/2, top+line+quiet)
+
+	// frame with a gap in the bottom line for the caption
+	textX := left + line + 2*s
+	textY := bottom - line/2 - textH/2
+	rects = append(rects,
+		image.Rect(left, top, right, top+line),
+		image.Rect(left, top, left+line, bottom),
+		image.Rect(right-line, top, right, bottom),
+		image.Rect(left, bottom-line, textX-s, bottom),
+		image.Rect(textX+textW+s, bottom-line, right, bottom),
+	)
+
+	x := textX
+	for _, r := range frameCaption {
+		for row, bits := range font[r] {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>uint(col)) != 0 {
+					rects = append(rects, image.Rect(x+col*g, textY+row*g, x+(col+1)*g, textY+(row+1)*g))
+				}
+			}
+		}
+		x += 6 * g
+	}
+
+	return size, rects
+}
+
+// encodeImage encodes the item as QR code at level M recommended by the SPAYD specification
+func encodeImage(it *abo.Item, dueDate time.Time, opts ImageOptions) (image.Point, []image.Rectangle, error) {
+	str, err := Encode(it, dueDate)
+	if err != nil {
+		return image.Point{}, nil, err
+	}
+	code, err := qr.Encode([]byte(str), qr.M)
+	if err != nil {
+		return image.Point{}, nil, err
+	}
+
+	size, rects := layout(code, opts)
+	return size, rects, nil
+}
+
+// WritePNG renders the item as QR payment PNG image
+func WritePNG(wr io.Writer, it *abo.Item, dueDate time.Time, opts ImageOptions) error {
+	size, rects, err := encodeImage(it, dueDate, opts)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, size.X, size.Y), color.Palette{color.White, color.Black})
+	for _, r := range rects {
+		draw.Draw(img, r, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+
+	return png.Encode(wr, img)
+}
+
+// WriteSVG renders the item as QR payment SVG image
+func WriteSVG(wr io.Writer, it *abo.Item, dueDate time.Time, opts ImageOptions) error {
+	size, rects, err := encodeImage(it, dueDate, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(wr)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		size.X, size.Y, size.X, size.Y)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", size.X, size.Y)
+	bw.WriteString(`<path fill="#000" d="`)
+	for i, r := range rects {
+		if i > 0 {
+			bw.WriteByte(' ')
+		}
+		fmt.Fprintf(bw, "M%d %dh%dv%dh-%dz", r.Min.X, r.Min.Y, r.Dx(), r.Dy(), r.Dx())
+	}
+	bw.WriteString("\"/>\n</svg>\n")
+
+	return bw.Flush()
+}
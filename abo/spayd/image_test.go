@@ -0,0 +1,67 @@
+package spayd
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3a/ago/abo"
+)
+
+func testItem() *abo.Item {
+	it := new(abo.Item)
+	it.Recipient.AccountNumPrefix = 19
+	it.Recipient.AccountNum = 2000145399
+	it.Recipient.BankCode = 800
+	it.Amount = 480.5
+	it.VS = 1234567890
+	return it
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, testItem(), time.Time{}, ImageOptions{Scale: 2}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// version 5 code with quiet zone
+	if b := img.Bounds(); b.Dx() != (37+8)*2 || b.Dy() != b.Dx() {
+		t.Fatalf("unexpected image size %v", b)
+	}
+	if r, _, _, _ := img.At(8, 8).RGBA(); r != 0 {
+		t.Fatal("finder pattern is not dark")
+	}
+
+	buf.Reset()
+	if err := WritePNG(&buf, testItem(), time.Time{}, ImageOptions{Scale: 2, Frame: true}); err != nil {
+		t.Fatal(err)
+	}
+	if img, err = png.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() <= (37+8)*2 {
+		t.Fatalf("frame not drawn, image size %v", b)
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, testItem(), time.Time{}, ImageOptions{Frame: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, `<svg xmlns="http://www.w3.org/2000/svg"`) || !strings.Contains(out, `<path fill="#000" d="M`) {
+		t.Fatalf("unexpected SVG %s", out)
+	}
+
+	it := testItem()
+	it.Recipient.BankCode = 0
+	if err := WriteSVG(&buf, it, time.Time{}, ImageOptions{}); err == nil {
+		t.Fatal("invalid item not rejected")
+	}
+}
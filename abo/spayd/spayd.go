@@ -0,0 +1,245 @@
+// Package spayd encodes and decodes Czech QR payments (QR Platba) in the
+// Short Payment Descriptor format, e.g. "SPD*1.0*ACC:CZ...*AM:480.50*CC:CZK".
+package spayd
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/k3a/ago/abo"
+	"github.com/k3a/ago/abo/currency"
+)
+
+func newErr(format string, args ...interface{}) error {
+	return fmt.Errorf("spayd: "+format, args...)
+}
+
+const (
+	header     = "SPD*1.0*"
+	dateFormat = "20060102"
+)
+
+// maximum value lengths in characters
+const (
+	maxAmount  = 10
+	maxMessage = 60
+	maxName    = 35
+	maxSymbol  = 9999999999
+)
+
+var escaper = strings.NewReplacer("%", "%25", "*", "%2A")
+
+// canonical returns the descriptor with keys sorted alphabetically,
+// which is the form the CRC32 is computed from
+func canonical(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('*')
+		}
+		sb.WriteString(k + ":" + fields[k])
+	}
+	return sb.String()
+}
+
+// checksum returns CRC32 of the canonical descriptor as 8 uppercase hex digits
+func checksum(fields map[string]string) string {
+	return fmt.Sprintf("%08X", crc32.ChecksumIEEE([]byte(canonical(fields))))
+}
+
+// Encode returns the payment descriptor of the item due on dueDate, which may be zero.
+// Recipient account is encoded as IBAN, derived from the account number if not set.
+// Amount is in CZK if the item currency is unknown.
+func Encode(it *abo.Item, dueDate time.Time) (string, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(it.Recipient.IBAN, " ", ""))
+	if iban == "" {
+		if it.Recipient.AccountNum == 0 || it.Recipient.BankCode == 0 {
+			return "", newErr("missing recipient account or bank code")
+		}
+		iban = abo.Account{
+			Prefix:   it.Recipient.AccountNumPrefix,
+			Number:   it.Recipient.AccountNum,
+			BankCode: it.Recipient.BankCode,
+		}.IBAN()
+	}
+	if err := abo.ValidateIBAN(iban); err != nil {
+		return "", newErr("invalid recipient IBAN: %w", err)
+	}
+
+	fields := map[string]string{"ACC": iban}
+	if it.Recipient.BIC != "" {
+		fields["ACC"] += "+" + strings.ToUpper(it.Recipient.BIC)
+	}
+
+	if it.Amount < 0 {
+		return "", newErr("negative amount %.2f", it.Amount)
+	}
+	if it.Amount > 0 {
+		halere := abo.ToHalere(it.Amount)
+		am := fmt.Sprintf("%d.%02d", halere/100, halere%100)
+		if len(am) > maxAmount {
+			return "", newErr("amount %s is too long", am)
+		}
+		fields["AM"] = am
+	}
+
+	ccy := it.Currency
+	if ccy == currency.Unknown {
+		ccy = currency.CZK
+	}
+	fields["CC"] = ccy.String()
+
+	for _, sym := range []struct {
+		key string
+		val int
+	}{{"X-VS", it.VS}, {"X-KS", it.KS}, {"X-SS", it.SS}} {
+		if sym.val < 0 || sym.val > maxSymbol {
+			return "", newErr("%s %d out of range", sym.key, sym.val)
+		}
+		if sym.val != 0 {
+			fields[sym.key] = strconv.Itoa(sym.val)
+		}
+	}
+
+	if msg := it.MessageForRecipient; msg != "" {
+		if utf8.RuneCountInString(msg) > maxMessage {
+			return "", newErr("message %q is longer than %d characters", msg, maxMessage)
+		}
+		fields["MSG"] = escaper.Replace(msg)
+	}
+	if name := it.Recipient.Name; name != "" {
+		if utf8.RuneCountInString(name) > maxName {
+			return "", newErr("recipient name %q is longer than %d characters", name, maxName)
+		}
+		fields["RN"] = escaper.Replace(name)
+	}
+	if !dueDate.IsZero() {
+		fields["DT"] = dueDate.Format(dateFormat)
+	}
+
+	return canonical(fields) + "*CRC32:" + checksum(fields), nil
+}
+
+// knownKeys are the keys defined by SPAYD 1.0, other keys must have X- prefix
+var knownKeys = map[string]bool{
+	"ACC": true, "ALT-ACC": true, "AM": true, "CC": true, "RF": true, "RN": true, "DT": true,
+	"PT": true, "MSG": true, "CRC32": true, "NT": true, "NTA": true,
+}
+
+// Decode parses the payment descriptor into a payment order item and its due date,
+// zero if not set. Only domestic payments in CZK are accepted as KPC orders can't
+// carry other payments. CRC32 is verified if present.
+func Decode(str string) (*abo.Item, time.Time, error) {
+	var dueDate time.Time
+
+	if !strings.HasPrefix(str, header) {
+		return nil, dueDate, newErr("invalid header, expected %q", header)
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimSuffix(str[len(header):], "*"), "*") {
+		idx := strings.IndexByte(field, ':')
+		if idx <= 0 {
+			return nil, dueDate, newErr("invalid field %q", field)
+		}
+		key, val := field[:idx], field[idx+1:]
+		if !knownKeys[key] && !strings.HasPrefix(key, "X-") {
+			return nil, dueDate, newErr("unknown key %s", key)
+		}
+		if _, ok := fields[key]; ok {
+			return nil, dueDate, newErr("duplicate key %s", key)
+		}
+		fields[key] = val
+	}
+
+	if crc, ok := fields["CRC32"]; ok {
+		delete(fields, "CRC32")
+		if exp := checksum(fields); !strings.EqualFold(crc, exp) {
+			return nil, dueDate, newErr("CRC32 mismatch, expected %s, got %s", exp, crc)
+		}
+	}
+
+	for key, val := range fields {
+		unescaped, err := url.PathUnescape(val)
+		if err != nil {
+			return nil, dueDate, newErr("invalid escaping of %s: %w", key, err)
+		}
+		fields[key] = unescaped
+	}
+
+	it := new(abo.Item)
+
+	acc, ok := fields["ACC"]
+	if !ok {
+		return nil, dueDate, newErr("missing ACC")
+	}
+	iban := strings.ToUpper(acc)
+	if idx := strings.IndexByte(acc, '+'); idx >= 0 {
+		iban, it.Recipient.BIC = iban[:idx], iban[idx+1:]
+	}
+	if err := abo.ValidateIBAN(iban); err != nil {
+		return nil, dueDate, newErr("invalid ACC: %w", err)
+	}
+	if !strings.HasPrefix(iban, "CZ") {
+		return nil, dueDate, newErr("foreign account %s can't be paid by KPC order", iban)
+	}
+	account, err := abo.AccountFromIBAN(iban)
+	if err != nil {
+		return nil, dueDate, newErr("invalid ACC: %w", err)
+	}
+	it.Recipient.IBAN = iban
+	it.Recipient.AccountNumPrefix = account.Prefix
+	it.Recipient.AccountNum = account.Number
+	it.Recipient.BankCode = account.BankCode
+
+	if cc, ok := fields["CC"]; ok && cc != "CZK" {
+		return nil, dueDate, newErr("currency %s can't be paid by KPC order", cc)
+	}
+	it.Currency = currency.CZK
+
+	if am, ok := fields["AM"]; ok {
+		if len(am) > maxAmount {
+			return nil, dueDate, newErr("AM %s is too long", am)
+		}
+		if it.Amount, err = strconv.ParseFloat(am, 64); err != nil || it.Amount < 0 {
+			return nil, dueDate, newErr("invalid AM %s", am)
+		}
+	}
+
+	for _, sym := range []struct {
+		key string
+		val *int
+	}{{"X-VS", &it.VS}, {"X-KS", &it.KS}, {"X-SS", &it.SS}} {
+		str, ok := fields[sym.key]
+		if !ok {
+			continue
+		}
+		if *sym.val, err = strconv.Atoi(str); err != nil || len(str) > 10 || *sym.val < 0 {
+			return nil, dueDate, newErr("invalid %s %s", sym.key, str)
+		}
+	}
+
+	it.MessageForRecipient = fields["MSG"]
+	it.Recipient.Name = fields["RN"]
+
+	if dt, ok := fields["DT"]; ok {
+		if dueDate, err = time.Parse(dateFormat, dt); err != nil {
+			return nil, dueDate, newErr("invalid DT %s", dt)
+		}
+	}
+
+	return it, dueDate, nil
+}
@@ -0,0 +1,96 @@
+package spayd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k3a/ago/abo"
+	"github.com/k3a/ago/abo/currency"
+)
+
+func TestEncode(t *testing.T) {
+	it := new(abo.Item)
+	it.Recipient.AccountNumPrefix = 19
+	it.Recipient.AccountNum = 2000145399
+	it.Recipient.BankCode = 800
+	it.Amount = 480.5
+	it.VS = 1234567890
+	it.KS = 308
+	it.MessageForRecipient = "Platba 100% * 2"
+
+	str, err := Encode(it, time.Date(2024, 1, 24, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "SPD*1.0*ACC:CZ6508000000192000145399*AM:480.50*CC:CZK*DT:20240124*" +
+		"MSG:Platba 100%25 %2A 2*X-KS:308*X-VS:1234567890*CRC32:6F04A41A"
+	if str != exp {
+		t.Fatalf("expected\n%s\ngot\n%s", exp, str)
+	}
+
+	it.Recipient.IBAN = "DE89 3704 0044 0532 0130 00"
+	it.Recipient.BIC = "COBADEFFXXX"
+	it.Currency = currency.EUR
+	it.MessageForRecipient = ""
+	it.KS = 0
+	it.VS = 0
+
+	if str, err = Encode(it, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(str, "SPD*1.0*ACC:DE89370400440532013000+COBADEFFXXX*AM:480.50*CC:EUR*CRC32:") {
+		t.Fatalf("unexpected descriptor %s", str)
+	}
+
+	it.MessageForRecipient = strings.Repeat("x", 61)
+	if _, err = Encode(it, time.Time{}); err == nil {
+		t.Fatal("long message not rejected")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	str := "SPD*1.0*ACC:CZ6508000000192000145399*AM:480.50*CC:CZK*DT:20240124*" +
+		"MSG:Platba 100%25 %2A 2*X-KS:308*X-VS:1234567890*CRC32:6f04a41a"
+
+	it, dueDate, err := Decode(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it.Recipient.AccountNumPrefix != 19 || it.Recipient.AccountNum != 2000145399 || it.Recipient.BankCode != 800 {
+		t.Fatalf("unexpected recipient %+v", it.Recipient)
+	}
+	if it.Amount != 480.5 || it.VS != 1234567890 || it.KS != 308 || it.MessageForRecipient != "Platba 100% * 2" {
+		t.Fatalf("unexpected item %+v", it)
+	}
+	if !dueDate.Equal(time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected due date %v", dueDate)
+	}
+
+	// round trip
+	if enc, err := Encode(it, dueDate); err != nil || enc != strings.Replace(str, "*CRC32:6f04a41a", "*CRC32:6F04A41A", 1) {
+		t.Fatalf("round trip failed: %s %v", enc, err)
+	}
+
+	for _, bad := range []string{
+		"SPD*2.0*ACC:CZ6508000000192000145399",
+		"SPD*1.0*AM:100.00",
+		"SPD*1.0*ACC:CZ6508000000192000145399*FOO:1",
+		"SPD*1.0*ACC:CZ6508000000192000145399*AM:1*AM:2",
+		"SPD*1.0*ACC:CZ6508000000192000145398",
+		"SPD*1.0*ACC:CZ6508000000192000145399*CC:EUR",
+		"SPD*1.0*ACC:DE89370400440532013000*CC:CZK",
+		"SPD*1.0*ACC:CZ6508000000192000145399*X-VS:12345678901",
+		"SPD*1.0*ACC:CZ6508000000192000145399*AM:480.50*CRC32:6F04A41A",
+	} {
+		if _, _, err := Decode(bad); err == nil {
+			t.Fatalf("%s not rejected", bad)
+		}
+	}
+
+	// custom X- keys are allowed
+	if _, _, err := Decode("SPD*1.0*ACC:CZ6508000000192000145399*X-FOO:bar*"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,97 @@
+package abo
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// SplitLimits describes the maximum size of an order accepted by a bank.
+// Zero value of a field means no limit.
+type SplitLimits struct {
+	MaxItemsPerGroup  int
+	MaxItemsPerOrder  int
+	MaxAmountPerOrder float64
+}
+
+// Split partitions the order into several orders, each satisfying the limits.
+// Groups are split as needed while preserving item order. Items are shared
+// with the original order, not copied. The resulting orders are numbered
+// sequentially starting with the Number of the original order.
+// Groups without items are rejected as they can't be written to a bank.
+func (or *Order) Split(limits SplitLimits) ([]*Order, error) {
+	var orders []*Order
+	var cur *Order
+	var curItems int
+	var curAmount int64
+
+	maxAmount := ToHalere(limits.MaxAmountPerOrder)
+
+	newOrder := func() {
+		cur = &Order{CreationDate: or.CreationDate, Client: or.Client, Number: or.Number + len(orders)}
+		orders = append(orders, cur)
+		curItems, curAmount = 0, 0
+	}
+
+	for i, gr := range or.Groups {
+		if len(gr.Items) == 0 {
+			return nil, newErr("group %d of payer account %d has no items", i+1, gr.Payer.AccountNum)
+		}
+
+		var curGroup *Group
+
+		for _, it := range gr.Items {
+			amount := ToHalere(it.Amount)
+			if maxAmount > 0 && amount > maxAmount {
+				return nil, newErr("item amount %.2f exceeds the order amount limit %.2f", it.Amount, limits.MaxAmountPerOrder)
+			}
+
+			if cur == nil ||
+				(limits.MaxItemsPerOrder > 0 && curItems >= limits.MaxItemsPerOrder) ||
+				(maxAmount > 0 && curAmount+amount > maxAmount) {
+				newOrder()
+				curGroup = nil
+			}
+
+			if curGroup == nil || (limits.MaxItemsPerGroup > 0 && len(curGroup.Items) >= limits.MaxItemsPerGroup) {
+				curGroup = cur.AddGroup(gr.Payer.AccountNumPrefix, gr.Payer.AccountNum, gr.DueDate)
+			}
+
+			curGroup.Items = append(curGroup.Items, it)
+			curItems++
+			curAmount += amount
+		}
+	}
+
+	// keep a single order even if there are no items
+	if cur == nil {
+		newOrder()
+	}
+
+	return orders, nil
+}
+
+// FileName returns a deterministic .kpc file name for the order
+// based on its creation date and sequence number
+func (or *Order) FileName() string {
+	return fmt.Sprintf("%s_%03d.kpc", or.CreationDate.Format("20060102"), or.Number)
+}
+
+// WriteToDir splits the order according to the limits and writes
+// the resulting orders to the directory, returning paths of the written files
+func (or *Order) WriteToDir(dir string, limits SplitLimits, opts FileOptions) ([]string, error) {
+	orders, err := or.Split(limits)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, o := range orders {
+		path := filepath.Join(dir, o.FileName())
+		if err := o.WriteToFileOpts(path, opts); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,57 @@
+package abo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	o := new(Order)
+	o.CreationDate = time.Date(2024, 9, 18, 0, 0, 0, 0, time.UTC)
+	o.Client.BankCode = 2010
+	o.Number = 1
+
+	gr := o.AddGroup(0, 2101135843, o.CreationDate.Add(24*time.Hour))
+	for i := 0; i < 5; i++ {
+		gr.AddItemSimple(0, 1900133399, 2010, 100, i+1, "")
+	}
+	gr2 := o.AddGroup(0, 2101135851, o.CreationDate.Add(24*time.Hour))
+	gr2.AddItemSimple(0, 1900133399, 2010, 250, 6, "")
+
+	orders, err := o.Split(SplitLimits{MaxItemsPerGroup: 2, MaxItemsPerOrder: 3, MaxAmountPerOrder: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3+2 items of the first group, then 250 alone
+	if len(orders) != 3 {
+		t.Fatalf("expected 3 orders, got %d", len(orders))
+	}
+	if len(orders[0].Groups) != 2 || len(orders[0].Groups[0].Items) != 2 || len(orders[0].Groups[1].Items) != 1 {
+		t.Fatal("bad groups in the first order")
+	}
+	if len(orders[1].Groups) != 1 || len(orders[1].Groups[0].Items) != 2 {
+		t.Fatal("bad groups in the second order")
+	}
+	if orders[2].Groups[0].Payer.AccountNum != 2101135851 || orders[2].Number != 3 {
+		t.Fatal("bad third order")
+	}
+
+	if _, err := o.Split(SplitLimits{MaxAmountPerOrder: 200}); err == nil {
+		t.Fatal("expected error for item exceeding amount limit")
+	}
+
+	paths, err := o.WriteToDir(t.TempDir(), SplitLimits{MaxItemsPerOrder: 4}, FileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[1]) != "20240918_002.kpc" {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+
+	o.AddGroup(0, 2101135860, o.CreationDate)
+	if _, err := o.Split(SplitLimits{}); err == nil {
+		t.Fatal("expected error for group without items")
+	}
+}
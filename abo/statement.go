@@ -27,6 +27,25 @@ type Transaction struct {
 	DueDate  time.Time
 }
 
+// Transaction types
+const (
+	TypeDebit        = 1
+	TypeCredit       = 2
+	TypeStornoDebit  = 4
+	TypeStornoCredit = 5
+)
+
+// IsDebit reports whether the transaction decreases the balance,
+// i.e. it is a debit or a reversal of a credit
+func (txn *Transaction) IsDebit() bool {
+	return txn.Type == TypeDebit || txn.Type == TypeStornoCredit
+}
+
+// IsReversal reports whether the transaction is a reversal (storno)
+func (txn *Transaction) IsReversal() bool {
+	return txn.Type == TypeStornoDebit || txn.Type == TypeStornoCredit
+}
+
 var errNoMoreTransactions = newErr("no more transactions in the input")
 
 func (txn *Transaction) String() string {
@@ -151,6 +170,9 @@ type Statement struct {
 		IncomeSum       float64
 		ExpenseSum      float64
 		StatementNumber int
+		// BankCode and Currency of the account, zero if not provided by the format
+		BankCode int
+		Currency currency.Currency
 	}
 
 	Transactions []*Transaction
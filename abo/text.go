@@ -0,0 +1,121 @@
+package abo
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextPolicy determines handling of characters not accepted in text fields
+type TextPolicy int
+
+const (
+	// TextStrict fails on characters not representable in Windows-1250
+	TextStrict TextPolicy = iota
+	// TextTransliterate converts text to plain ASCII, removing diacritics
+	TextTransliterate
+	// TextReplace replaces characters not representable in Windows-1250
+	TextReplace
+)
+
+// TextChange reports a text value changed by sanitation
+type TextChange struct {
+	Field   string
+	Value   string
+	Written string
+}
+
+// translit maps characters which can't be decomposed to ASCII
+var translit = map[rune]string{
+	'€': "EUR", '£': "GBP", '¥': "JPY", 'ß': "ss", 'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O", 'đ': "d", 'Đ': "D", 'ł': "l",
+	'Ł': "L", 'ı': "i", 'þ': "th", 'Þ': "Th", 'ð': "d", 'Ð': "D",
+	'„': "\"", '“': "\"", '”': "\"", '»': "\"", '«': "\"", '‚': "'", '‘': "'",
+	'’': "'", '–': "-", '—': "-", '…': "...", '×': "x", '°': "o", '§': "S",
+	' ': " ",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "ch", 'ц': "c", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "ju", 'я': "ja", 'і': "i", 'ї': "ji",
+	'є': "je", 'ґ': "g",
+}
+
+// representable checks whether the rune can be written in a text field
+func representable(r rune) bool {
+	if unicode.IsControl(r) {
+		return false
+	}
+	_, ok := charmap.Windows1250.EncodeRune(r)
+	return ok
+}
+
+// invisible runes (combining marks, joiners, emoji modifiers)
+// are dropped instead of being replaced
+func invisible(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Variation_Selector) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// transliterateRune returns ASCII representation of the rune if there is one
+func transliterateRune(r rune) (string, bool) {
+	if r < unicode.MaxASCII && !unicode.IsControl(r) {
+		return string(r), true
+	}
+
+	if s, ok := translit[r]; ok {
+		return s, true
+	}
+	if s, ok := translit[unicode.ToLower(r)]; ok {
+		// capitalize transliteration of an upper-case letter
+		if len(s) > 0 {
+			s = strings.ToUpper(s[:1]) + s[1:]
+		}
+		return s, true
+	}
+
+	// strip diacritics
+	var out []rune
+	for _, dr := range norm.NFD.String(string(r)) {
+		if dr < unicode.MaxASCII && !unicode.IsControl(dr) {
+			out = append(out, dr)
+		}
+	}
+	return string(out), len(out) > 0
+}
+
+// sanitizeText applies the policy to the text, replacing unsupported characters by repl
+func sanitizeText(field, str string, policy TextPolicy, repl string) (string, error) {
+	var sb strings.Builder
+
+	for _, r := range norm.NFC.String(str) {
+		switch {
+		case policy == TextTransliterate:
+			if s, ok := transliterateRune(r); ok {
+				sb.WriteString(s)
+				continue
+			}
+			if invisible(r) {
+				continue
+			}
+		case representable(r):
+			sb.WriteRune(r)
+			continue
+		case policy == TextStrict:
+			return "", newErr("character %q of field %s is not representable in Windows-1250", r, field)
+		case invisible(r):
+			continue
+		}
+
+		if unicode.IsSpace(r) {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteString(repl)
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,32 @@
+package abo
+
+import "testing"
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		policy TextPolicy
+		in     string
+		out    string
+	}{
+		{TextStrict, "Žluťoučký kůň", "Žluťoučký kůň"},
+		{TextTransliterate, "Žluťoučký kůň", "Zlutoucky kun"},
+		{TextTransliterate, "Faktura 5€ – Щука", "Faktura 5EUR - Shchuka"},
+		{TextReplace, "Platba 5€ 👍🏻 kůň", "Platba 5€ ? kůň"},
+		{TextReplace, "Иван\nNový", "???? Nový"},
+		{TextTransliterate, "Nová\tfaktura 日本", "Nova faktura ??"},
+	}
+
+	for _, tc := range tests {
+		out, err := sanitizeText("test", tc.in, tc.policy, "?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tc.out {
+			t.Fatalf("expected %q for %q, got %q", tc.out, tc.in, out)
+		}
+	}
+
+	if _, err := sanitizeText("test", "Иван", TextStrict, "?"); err == nil {
+		t.Fatal("expected error for Cyrillic in strict mode")
+	}
+}
@@ -1,7 +1,29 @@
 package abo
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 func newErr(format string, args ...interface{}) error {
 	return fmt.Errorf("abo: "+format, args...)
 }
+
+// ToHalere converts monetary amount to integer hundredths. Amounts are compared
+// in hundredths throughout the module, including subpackages, to avoid float rounding errors.
+func ToHalere(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// roundAmount rounds monetary amount to hundredths
+func roundAmount(amount float64) float64 {
+	return float64(ToHalere(amount)) / 100
+}
+
+// CalendarDay returns the date at midnight UTC for calendar day comparisons.
+// Subpackages use it to compare dates of statements, orders and transactions.
+func CalendarDay(tm time.Time) time.Time {
+	y, m, d := tm.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}